@@ -0,0 +1,142 @@
+package xbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rs/zerolog/log"
+	"github.com/thoas/go-funk"
+)
+
+// TestIDAttribute is the attribute used by GetByTestID
+var TestIDAttribute = "data-testid"
+
+// labelledRoles are the roles of form controls which can be labelled
+var labelledRoles = []string{
+	"textbox", "searchbox", "combobox", "listbox", "checkbox", "radio",
+	"switch", "slider", "spinbutton", "menuitemcheckbox", "menuitemradio",
+}
+
+// GetByRole returns the element with ARIA role, and with the accessible name if name is not empty,
+// it waits like GetElem, and supports ElemIndex/WithRoot/WithFrame
+//
+//	e.g. b.GetByRole("button", "Sign in"), b.GetByRole("link", "Next", ElemIndex(-1))
+func (b *Bot) GetByRole(role, name string, opts ...BotOptFunc) *rod.Element {
+	return b.getByAX(proto.AccessibilityQueryAXTree{Role: role, AccessibleName: name}, nil, opts...)
+}
+
+// GetByLabel returns the form control whose accessible name is label,
+// which is computed from <label>, aria-label or aria-labelledby
+func (b *Bot) GetByLabel(label string, opts ...BotOptFunc) *rod.Element {
+	return b.getByAX(proto.AccessibilityQueryAXTree{AccessibleName: label}, labelledRoles, opts...)
+}
+
+// GetByPlaceholder returns the element whose placeholder equals to text
+func (b *Bot) GetByPlaceholder(text string, opts ...BotOptFunc) *rod.Element {
+	return b.getByAttr("placeholder", text, opts...)
+}
+
+// GetByTestID returns the element whose TestIDAttribute equals to id
+func (b *Bot) GetByTestID(id string, opts ...BotOptFunc) *rod.Element {
+	return b.getByAttr(TestIDAttribute, id, opts...)
+}
+
+func (b *Bot) getByAttr(attr, value string, opts ...BotOptFunc) *rod.Element {
+	opt := BotOpts{ElemIndex: 0}
+	BindBotOpts(&opt, opts...)
+	return b.GetElem(Sel(attrSelector(attr, value)).At(opt.ElemIndex), opts...)
+}
+
+// attrSelector returns css which matches attr equals to value
+func attrSelector(attr, value string) string {
+	return fmt.Sprintf(`[%s=%q]`, attr, value)
+}
+
+// getByAX polls the accessibility tree until nodes of query appear, or timeout
+func (b *Bot) getByAX(query proto.AccessibilityQueryAXTree, roles []string, opts ...BotOptFunc) *rod.Element {
+	opt := BotOpts{ElemIndex: 0, Timeout: toSec(b.mediumToSec), root: b.root}
+	BindBotOpts(&opt, opts...)
+
+	// frame resolution and the polling share the deadline
+	deadline := time.Now().Add(time.Duration(opt.Timeout) * time.Second)
+	sel, err := b.bindFrame(Selector{}, &opt)
+	if err != nil {
+		log.Debug().Err(err).Msg("getByAX")
+		return nil
+	}
+	pg := b.pageOf(sel)
+
+	// root is got once for all polls, the document one is got again only if it's replaced by navigation
+	root := opt.root
+	for {
+		var elems []*rod.Element
+		if root == nil {
+			root, err = documentRoot(pg.Timeout(b.shortToSec))
+		}
+		if root != nil {
+			elems, err = queryAX(pg, root, query, roles)
+			if err != nil && opt.root == nil {
+				root = nil
+			}
+		}
+		if err != nil {
+			log.Debug().Err(err).Str("role", query.Role).Str("name", query.AccessibleName).Msg("query accessibility tree")
+		}
+
+		if i, ok := Sel("").At(opt.ElemIndex).index(len(elems)); ok {
+			return elems[i]
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil
+		}
+		if err := b.randSleep(0.2, 0.3); err != nil {
+			return nil
+		}
+	}
+}
+
+// documentRoot returns the document element of pg
+func documentRoot(pg *rod.Page) (*rod.Element, error) {
+	root, err := pg.ElementByJS(rod.Eval(`() => document.documentElement`))
+	if err != nil {
+		return nil, err
+	}
+	return root.CancelTimeout(), nil
+}
+
+// queryAX returns elements of nodes in accessibility tree of root
+func queryAX(pg *rod.Page, root *rod.Element, query proto.AccessibilityQueryAXTree, roles []string) ([]*rod.Element, error) {
+	query.ObjectID = root.Object.ObjectID
+	res, err := query.Call(pg)
+	if err != nil {
+		return nil, err
+	}
+
+	var elems []*rod.Element
+	for _, id := range axNodeIDs(res.Nodes, roles) {
+		elem, err := pg.ElementFromNode(&proto.DOMNode{BackendNodeID: id})
+		if err != nil {
+			return elems, err
+		}
+		elems = append(elems, elem)
+	}
+
+	return elems, nil
+}
+
+// axNodeIDs returns the dom nodes of ax nodes which are not ignored, and with one of roles if roles is not nil
+func axNodeIDs(nodes []*proto.AccessibilityAXNode, roles []string) (ids []proto.DOMBackendNodeID) {
+	for _, node := range nodes {
+		if node.Ignored || node.BackendDOMNodeID == 0 {
+			continue
+		}
+		if roles != nil && (node.Role == nil || !funk.ContainsString(roles, node.Role.Value.Str())) {
+			continue
+		}
+		ids = append(ids, node.BackendDOMNodeID)
+	}
+	return ids
+}
@@ -0,0 +1,65 @@
+package xbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/suite"
+	"github.com/ysmood/gson"
+)
+
+type A11ySuite struct {
+	suite.Suite
+}
+
+func TestA11y(t *testing.T) {
+	suite.Run(t, new(A11ySuite))
+}
+
+func (s *A11ySuite) Test_01_AttrSelector() {
+	s.Equal(`[placeholder="Search"]`, attrSelector("placeholder", "Search"))
+	s.Equal(`[data-testid="say \"hi\""]`, attrSelector(TestIDAttribute, `say "hi"`))
+	s.True(Sel(attrSelector("placeholder", "Search")).isPlainCSS())
+}
+
+func (s *A11ySuite) Test_02_NodeIDs() {
+	role := func(r string) *proto.AccessibilityAXValue {
+		return &proto.AccessibilityAXValue{Value: gson.New(r)}
+	}
+	nodes := []*proto.AccessibilityAXNode{
+		{BackendDOMNodeID: 1, Role: role("textbox")},
+		{BackendDOMNodeID: 2, Role: role("button")},
+		{BackendDOMNodeID: 3, Role: role("textbox"), Ignored: true},
+		{Role: role("textbox")},
+		{BackendDOMNodeID: 5},
+	}
+
+	s.Equal([]proto.DOMBackendNodeID{1, 2, 5}, axNodeIDs(nodes, nil))
+	s.Equal([]proto.DOMBackendNodeID{1}, axNodeIDs(nodes, labelledRoles), "only labelled roles")
+	s.Empty(axNodeIDs(nodes, []string{"link"}))
+}
+
+func (s *A11ySuite) Test_03_Query() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body>
+			<label for=email>Email</label><input id=email>
+			<button aria-label="Email">icon</button>
+			<button>Sign in</button><a href="#a">Next</a><a href="#b">Next</a>
+		</body></html>`))
+	}))
+	defer srv.Close()
+
+	b, err := NewBotE(BotUserAgent(UA), BotHeadless(true))
+	if err != nil {
+		s.T().Skipf("browser not available: %v", err)
+	}
+	defer b.Close()
+	s.Require().NoError(b.GetPageE(srv.URL))
+
+	s.Equal("Sign in", b.GetByRole("button", "Sign in").MustText())
+	s.Equal(srv.URL+"/#b", b.GetByRole("link", "Next", ElemIndex(-1)).MustProperty("href").Str())
+	s.Equal("email", b.GetByLabel("Email").MustProperty("id").Str(), "the button of same name is not a form control")
+	s.Nil(b.GetByRole("button", "Sign up", BotTimeout(1)))
+}
@@ -0,0 +1,137 @@
+package xbot
+
+import (
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// PropPrefix marks a key of GetElemsAttrs is a property, e.g. "prop:value", "prop:href"
+const PropPrefix = "prop:"
+
+// batchAttrsJS reads keys of all elements matched by selectors in one evaluation
+const batchAttrsJS = `function(sels, keys) {
+	const out = []
+	for (const s of sels) {
+		const elems = (` + selectElemsJS + `).call(this, s.css, s.xpath, s.source, s.flags)
+		for (const e of elems) {
+			const row = {}
+			for (const k of keys) {
+				const v = (` + readValueJS + `)(e, k.attr, k.prop)
+				if (v !== null && v !== undefined) row[k.key] = v
+			}
+			out.push(row)
+		}
+	}
+	return out
+}`
+
+type batchSelector struct {
+	CSS    string `json:"css"`
+	XPath  string `json:"xpath"`
+	Source string `json:"source"`
+	Flags  string `json:"flags"`
+}
+
+type batchKey struct {
+	Key  string `json:"key"`
+	Attr string `json:"attr"`
+	Prop string `json:"prop"`
+}
+
+func toBatchKeys(keys []string) []batchKey {
+	out := make([]batchKey, 0, len(keys))
+	for _, k := range keys {
+		bk := batchKey{Key: k, Attr: k}
+		if strings.HasPrefix(k, PropPrefix) {
+			bk.Attr, bk.Prop = "", strings.TrimPrefix(k, PropPrefix)
+		}
+		out = append(out, bk)
+	}
+	return out
+}
+
+// GetElemsAttrs reads keys of all elements matched by selectors in one Runtime evaluation,
+// rows are in the order of selectors then elements, and don't wait for elements to appear.
+//
+// a key is an attribute (e.g. "href", "data-id"), "innerText", "innerHTML", "outerHTML", "textContent",
+// or a property with PropPrefix (e.g. "prop:value"), missing ones are absent in the row.
+// WithRoot and WithFrame are supported.
+//
+//	e.g. rows, err := b.GetElemsAttrs([]string{"li.item a"}, []string{"innerText", "href", "prop:href"})
+func (b *Bot) GetElemsAttrs(selectors []string, keys []string, opts ...BotOptFunc) ([]map[string]string, error) {
+	all := make([]interface{}, 0, len(selectors))
+	for _, s := range selectors {
+		all = append(all, s)
+	}
+	return b.getElemsAttrs(all, keys, opts...)
+}
+
+// getElemsAttrs is GetElemsAttrs of any selectors GetElems accepts, so a Selector keeps its case-insensitivity and regex flags,
+// Nth and Root of Selector are ignored as all rows are read at once under WithRoot
+func (b *Bot) getElemsAttrs(selectors []interface{}, keys []string, opts ...BotOptFunc) ([]map[string]string, error) {
+	opt := BotOpts{root: b.root}
+	BindBotOpts(&opt, opts...)
+
+	scope, err := b.bindFrame(Selector{}, &opt)
+	if err != nil {
+		return nil, err
+	}
+
+	sels, err := b.toBatchSelectors(selectors, opt, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	js := rod.Eval(batchAttrsJS, sels, toBatchKeys(keys))
+	if opt.root != nil {
+		js = js.This(opt.root.Object)
+	}
+
+	obj, err := b.pageOf(scope).Timeout(b.mediumToSec).Evaluate(js)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	err = obj.Value.Unmarshal(&rows)
+	return rows, err
+}
+
+// toBatchSelectors converts selectors to what batchAttrsJS matches, chains are resolved to the selector which has elements,
+// and the empty ones are skipped
+func (b *Bot) toBatchSelectors(selectors []interface{}, opt BotOpts, opts ...BotOptFunc) ([]batchSelector, error) {
+	sels := make([]batchSelector, 0, len(selectors))
+	for _, v := range selectors {
+		// element name of registry or chain is resolved to its selector which has elements
+		if chain, ok := b.toChain(v); ok {
+			s, _, found := b.pickFromChain(chain, 0, opts...)
+			if !found {
+				continue
+			}
+			v = s
+		}
+
+		sel, err := toSelector(v)
+		if err != nil {
+			return nil, err
+		}
+		sel = opt.bindSelector(sel)
+		if sel.IsEmpty() {
+			continue
+		}
+
+		bs := batchSelector{CSS: sel.CSS, XPath: sel.XPath}
+		if sel.byText() {
+			bs.Source, bs.Flags = sel.textRegex()
+		}
+		sels = append(sels, bs)
+	}
+	return sels, nil
+}
+
+func (b *Bot) MustGetElemsAttrs(selectors []string, keys []string, opts ...BotOptFunc) []map[string]string {
+	rows, err := b.GetElemsAttrs(selectors, keys, opts...)
+	b.PanicIfErr(err)
+	return rows
+}
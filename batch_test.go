@@ -0,0 +1,78 @@
+package xbot
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type BatchSuite struct {
+	suite.Suite
+}
+
+func TestBatch(t *testing.T) {
+	suite.Run(t, new(BatchSuite))
+}
+
+func (s *BatchSuite) Test_01_Keys() {
+	got := toBatchKeys([]string{"href", "innerText", "prop:value"})
+	s.Equal([]batchKey{
+		{Key: "href", Attr: "href"},
+		{Key: "innerText", Attr: "innerText"},
+		{Key: "prop:value", Prop: "value"},
+	}, got)
+}
+
+// Test_02_JSSyntax checks the generated js with node if available
+func (s *BatchSuite) Test_02_JSSyntax() {
+	checkJSSyntax(s.T(), map[string]string{"batch": batchAttrsJS, "extract": extractJS})
+}
+
+// Test_03_Eval runs batchAttrsJS on a stub document with node if available
+func (s *BatchSuite) Test_03_Eval() {
+	sels, err := json.Marshal([]batchSelector{
+		{CSS: "li"},
+		{CSS: "input"},
+		{CSS: "li", Source: "^b", Flags: "i"},
+	})
+	s.Require().NoError(err)
+	keys, err := json.Marshal(toBatchKeys([]string{"data-id", "prop:value", "innerText", "title"}))
+	s.Require().NoError(err)
+
+	out := runNodeJS(s.T(), `
+const elem = (tagName, attrs, props) => Object.assign({tagName, getAttribute: (k) => k in attrs ? attrs[k] : null}, props);
+const byCSS = {
+	li: [elem("LI", {"data-id": "1"}, {innerText: "a"}), elem("LI", {"data-id": "2", title: "t"}, {innerText: "B"})],
+	input: [elem("INPUT", {}, {value: "typed", innerText: ""})],
+};
+const root = {querySelectorAll: (css) => byCSS[css] || []};
+console.log(JSON.stringify((`+batchAttrsJS+`).call(root, `+string(sels)+`, `+string(keys)+`)))`)
+
+	s.JSONEq(`[
+		{"data-id": "1", "innerText": "a"},
+		{"data-id": "2", "innerText": "B", "title": "t"},
+		{"prop:value": "typed", "innerText": ""},
+		{"data-id": "2", "innerText": "B", "title": "t"}
+	]`, out, "rows are in the order of selectors then elements, missing keys are absent")
+}
+
+// checkJSSyntax checks syntax of each js function with node, it skips if node is not found
+func checkJSSyntax(t *testing.T, scripts map[string]string) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found")
+	}
+
+	for name, js := range scripts {
+		path := filepath.Join(t.TempDir(), name+".js")
+		require.NoError(t, os.WriteFile(path, []byte("("+js+");"), 0o600))
+		out, err := exec.Command(node, "--check", path).CombinedOutput()
+		assert.NoError(t, err, "%s: %s", name, out)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"regexp"
 	"strings"
 	"time"
 
@@ -18,7 +19,6 @@ import (
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/gookit/goutil/dump"
 	"github.com/gookit/goutil/mathutil"
-	"github.com/gookit/goutil/strutil"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cast"
 	"github.com/thoas/go-funk"
@@ -39,69 +39,83 @@ func (b *Bot) PanicIfErr(err error) {
 	}
 }
 
+// DisableImages blocks images of brw, it's a rule of the browser's Router
 func (b *Bot) DisableImages(brw *rod.Browser) {
-	router := brw.HijackRequests()
-	router.MustAdd("*", func(ctx *rod.Hijack) {
-		if ctx.Request.Type() == proto.NetworkResourceTypeImage {
-			ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
-			return
-		}
-		ctx.ContinueRequest(&proto.FetchContinueRequest{})
-	})
-	go router.Run()
+	b.mustRouterOf(brw).MustAdd(RouteBlock("*").OfType(proto.NetworkResourceTypeImage).Named("disable-images"))
 }
 
-// DisableResources will hijack all resources
+// DisableResources blocks resources whose url contains any of resources, it's a rule of the browser's Router
 func (b *Bot) DisableResources(brw *rod.Browser, resources ...string) {
 	if len(resources) == 0 {
 		return
 	}
-	router := brw.HijackRequests()
-	router.MustAdd("*", func(ctx *rod.Hijack) {
-		for _, res := range resources {
-			if funk.Contains(ctx.Request.URL().String(), res) {
-				ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
-				return
-			}
-		}
-		ctx.ContinueRequest(&proto.FetchContinueRequest{})
-	})
-	go router.Run()
+
+	quoted := make([]string, 0, len(resources))
+	for _, res := range resources {
+		quoted = append(quoted, regexp.QuoteMeta(res))
+	}
+	b.mustRouterOf(brw).MustAdd(RouteBlock(RegexPrefix + strings.Join(quoted, "|")).Named("disable-resources"))
 }
 
+// HandleXHR calls cb with url and body of xhr matching res, it's a rule of the browser's Router,
+// RecordNetwork is preferred, which records fetch, headers and status codes too without hijacking
 func (b *Bot) HandleXHR(brw *rod.Browser, res string, cb func(a, b string)) {
-	router := brw.HijackRequests()
-	router.MustAdd(res, func(ctx *rod.Hijack) {
-		if ctx.Request.Type() == proto.NetworkResourceTypeXHR {
-			ctx.MustLoadResponse()
-			body := ctx.Response.Body()
-			uri := ctx.Request.URL().String()
-			cb(uri, body)
-		}
+	b.mustRouterOf(brw).MustAdd(RouteHandle(res, func(ctx *rod.Hijack) {
+		ctx.MustLoadResponse()
+		cb(ctx.Request.URL().String(), ctx.Response.Body())
 		ctx.ContinueRequest(&proto.FetchContinueRequest{})
-	})
-
-	go router.Run()
+	}).OfType(proto.NetworkResourceTypeXHR).Named("handle-xhr"))
 }
 
+// SetTimeout sets the timeout tiers from bot.Config,
+// each tier falls back to LongToSec/MediumToSec/ShortToSec/NapToSec if not set,
+// and all tiers are multiplied by Config.TimeoutScale
 func (b *Bot) SetTimeout() {
-	b.longToSec = LongToSec * time.Second
-	b.mediumToSec = MediumToSec * time.Second
-	b.shortToSec = ShortToSec * time.Second
-	b.NapToSec = NapToSec * time.Second
+	cfg := b.Config
+	if cfg == nil {
+		cfg = defaultCfg
+	}
+
+	scale := xutil.AorB(cfg.TimeoutScale, 1.0)
+	b.longToSec = scaleTimeout(xutil.AorB(cfg.LongTimeout, LongToSec), scale)
+	b.mediumToSec = scaleTimeout(xutil.AorB(cfg.MediumTimeout, MediumToSec), scale)
+	b.shortToSec = scaleTimeout(xutil.AorB(cfg.ShortTimeout, ShortToSec), scale)
+	b.NapToSec = scaleTimeout(xutil.AorB(cfg.NapTimeout, NapToSec), scale)
+	b.pageToSec = scaleTimeout(xutil.AorB(cfg.PageTimeout, LongToSec), scale)
+}
+
+func scaleTimeout(sec int, scale float64) time.Duration {
+	return time.Duration(float64(sec) * scale * float64(time.Second))
+}
+
+// toSec converts the timeout tier to seconds, which is used as default of BotOpts.Timeout
+func toSec(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
 
 func (b *Bot) GetPage(url string) {
-	b.Pg.Timeout(b.longToSec).MustNavigate(url)
-	b.Pg.Timeout(b.longToSec).MustWaitLoad()
+	if err := b.GetPageE(url); err != nil {
+		panic(err)
+	}
 }
 
-func (b *Bot) GetPageE(url string) error {
-	if e := b.Pg.Timeout(b.longToSec).Navigate(url); e != nil {
+// GetPageE navigates to url and waits page loaded,
+// proxy failures are reported to the proxy provider if bot is created WithProxyProvider
+func (b *Bot) GetPageE(url string) (err error) {
+	defer func() {
+		b.reportProxyResult(err)
+	}()
+
+	if e := b.Pg.Timeout(b.pageToSec).Navigate(url); e != nil {
 		return e
 	}
 
-	return b.Pg.Timeout(b.longToSec).WaitLoad()
+	if e := b.Pg.Timeout(b.pageToSec).WaitLoad(); e != nil {
+		return e
+	}
+
+	b.syncPopovers()
+	return nil
 }
 
 func (b *Bot) CurrentUrl() string {
@@ -111,16 +125,45 @@ func (b *Bot) CurrentUrl() string {
 // RetryWhenPanic
 //
 // args: tries, delay, showLogOrNot
-// check `xutil.EnsureByRetry` for more detail
+// works like `xutil.EnsureByRetry`: 3 tries by default, delay is in milliseconds
+//
+// if bot is bound with ctx by WithContext, retry stops once ctx is done (even in the delay between tries),
+// and ctx's error is returned
 func (b *Bot) RetryWhenPanic(fn func(), args ...int) (int, error) {
-	return xutil.EnsureByRetry(
+	var tries uint = 3
+	if v := xutil.FirstOrDefaultArgs(3, args...); v >= 0 {
+		tries = uint(v)
+	}
+
+	delay, show := 0, 0
+	if len(args) > 1 {
+		delay = args[1]
+	}
+	if len(args) > 2 {
+		show = args[2]
+	}
+
+	tried := 0
+	err := retry.Do(
 		func() error {
+			tried++
 			return rod.Try(func() {
 				fn()
 			})
 		},
-		args...,
+		retry.Attempts(tries),
+		retry.Delay(time.Millisecond*time.Duration(delay)),
+		retry.OnRetry(func(n uint, err error) {
+			if show > 0 {
+				log.Debug().Uint("n", n).Err(err).Msg("retry when panic")
+			}
+		}),
+		retry.Context(b.Context()),
 	)
+	if e := b.ctxErr(); e != nil {
+		return tried, e
+	}
+	return tried, err
 }
 
 // CatchPanic just a wrapper of rod.Try.
@@ -145,6 +188,8 @@ func (b *Bot) CatchPanicWithFb(fn func(), fb func() error) (err error) {
 	return
 }
 
+// BindPopovers binds popovers closed by CloseIfHasPopovers, they're kept across pages,
+// popovers of registry pages are closed along with them
 func (b *Bot) BindPopovers(p []string) {
 	b.popovers = p
 }
@@ -156,11 +201,15 @@ func (b *Bot) BindPopovers(p []string) {
 // - if failed, will try by Press Escape
 //
 // return total closed popovers
+//
+// with registry, popovers of the page matching current url are used
 func (b *Bot) CloseIfHasPopovers() (hit int) {
-	if len(b.popovers) == 0 {
+	b.syncPopovers()
+	popovers := b.allPopovers()
+	if len(popovers) == 0 {
 		return
 	}
-	for _, sel := range b.popovers {
+	for _, sel := range popovers {
 		hit += b.ClosePopover(sel)
 	}
 	if hit != 0 {
@@ -169,19 +218,16 @@ func (b *Bot) CloseIfHasPopovers() (hit int) {
 	return
 }
 
-func (b *Bot) ClosePopover(sel string) (hit int) {
-	elems, err := b.Pg.Elements(sel)
-	if err != nil {
-		log.Error().Err(err).Str("selector", sel).Msg("find")
-		return
-	}
+// ClosePopover clicks all interactable elements of sel, which is what GetElems accepts, e.g. string, Selector or element name
+func (b *Bot) ClosePopover(sel interface{}) (hit int) {
+	elems := b.GetElems(sel)
 	if len(elems) == 0 {
 		log.Trace().Msg("no popovers found")
 		return
 	}
 
 	for _, elem := range elems {
-		log.Debug().Str("popover", sel).Msg("try close")
+		log.Debug().Interface("popover", sel).Msg("try close")
 		if !elem.MustInteractable() {
 			elem.Overlay("popover is not interactable")
 			return
@@ -199,8 +245,8 @@ func (b *Bot) ClosePopover(sel string) (hit int) {
 
 // ClickPopoverByEsc close a popover by pressing escape
 // In many cases, can use CloseIfHasPopovers instead
-func (b *Bot) ClickPopoverByEsc(selector string, opts ...BotOptFunc) {
-	if selector == "" {
+func (b *Bot) ClickPopoverByEsc(selector interface{}, opts ...BotOptFunc) {
+	if funk.IsEmpty(selector) {
 		return
 	}
 
@@ -208,18 +254,18 @@ func (b *Bot) ClickPopoverByEsc(selector string, opts ...BotOptFunc) {
 	BindBotOpts(&opt, opts...)
 	elem := b.GetElem(selector, ElemIndex(opt.ElemIndex))
 	if elem != nil {
-		log.Debug().Str("popover", selector).Msg("Found Popover")
+		log.Debug().Interface("popover", selector).Msg("Found Popover")
 		b.Highlight(elem)
 		elem.Timeout(b.NapToSec).MustKeyActions().Press(input.Escape).MustDo()
 	}
 }
 
-func (b *Bot) MustPressEscape(sel string, opts ...BotOptFunc) {
+func (b *Bot) MustPressEscape(sel interface{}, opts ...BotOptFunc) {
 	err := b.PressEscape(sel, opts...)
 	b.PanicIfErr(err)
 }
 
-func (b *Bot) PressEscape(sel string, opts ...BotOptFunc) (err error) {
+func (b *Bot) PressEscape(sel interface{}, opts ...BotOptFunc) (err error) {
 	if elem := b.GetElem(sel, opts...); elem != nil {
 		// elem.MustKeyActions().Press(input.Escape).MustDo()
 		elem.Timeout(b.shortToSec).MustKeyActions().Press(input.Escape).MustDo()
@@ -228,7 +274,7 @@ func (b *Bot) PressEscape(sel string, opts ...BotOptFunc) (err error) {
 	return
 }
 
-func (b *Bot) PressTab(sel string, opts ...BotOptFunc) (err error) {
+func (b *Bot) PressTab(sel interface{}, opts ...BotOptFunc) (err error) {
 	if elem := b.GetElem(sel, opts...); elem != nil {
 		xutil.RandSleep(0.5, 0.51)
 		elem.MustKeyActions().Press(input.Tab).MustDo()
@@ -247,14 +293,106 @@ func (b *Bot) ensureHighlight(elem *rod.Element) {
 	b.Highlight(elem)
 }
 
-// EnsureAnyElem return the match elem
+// EnsureAnyElem waits for selectors at the same time (MediumTo), and returns the first one to appear,
+// see WaitAnyElem for selectors other than string and opts like BotTimeout/ElemIndex/WithRoot/WithFrame
 func (b *Bot) EnsureAnyElem(selectors ...string) (sel string, err error) {
+	all := make([]interface{}, 0, len(selectors))
+	for _, s := range selectors {
+		all = append(all, s)
+	}
+
+	i, _, err := b.WaitAnyElem(all)
+	if err != nil {
+		return "", err
+	}
+	return selectors[i], nil
+}
+
+// WaitAnyElem waits for selectors at the same time, and returns the index of the first one to appear
+// with its element, each selector is what GetElem accepts: string, Selector, SelectorChain or element name of registry,
+// an element name or chain matches if any of its alternatives matches.
+//
+// BotTimeout (MediumTo by default), ElemIndex, WithRoot and WithFrame work like GetElem, b.Iframe is used if bound
+//
+//	i, elem, err := b.WaitAnyElem([]interface{}{"div.result", XPath("//p[@class='empty']")}, BotTimeout(5))
+func (b *Bot) WaitAnyElem(selectors []interface{}, opts ...BotOptFunc) (index int, elem *rod.Element, err error) {
+	opt := BotOpts{ElemIndex: xutil.MaxInt, Timeout: toSec(b.mediumToSec), root: b.root}
+	BindBotOpts(&opt, opts...)
+
+	// frame resolution and the race share the deadline
+	deadline := time.Now().Add(time.Duration(opt.Timeout) * time.Second)
+	scope, err := b.bindFrame(Selector{}, &opt)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	var (
+		sels  []Selector
+		owner []int
+	)
+	for i, v := range selectors {
+		alts := []interface{}{v}
+		if chain, ok := b.toChain(v); ok {
+			alts = alts[:0]
+			for _, s := range chain.Selectors {
+				alts = append(alts, s)
+			}
+		}
+
+		for _, alt := range alts {
+			sel, err := toSelector(alt)
+			if err != nil {
+				return -1, nil, err
+			}
+			if sel.IsEmpty() {
+				continue
+			}
+			sel = opt.bindSelector(sel)
+			sel.frame = scope.frame
+			if opt.ElemIndex != xutil.MaxInt {
+				sel = sel.At(opt.ElemIndex)
+			}
+			sels, owner = append(sels, sel), append(owner, i)
+		}
+	}
+
+	var dur time.Duration
+	if opt.Timeout != 0 {
+		dur = remaining(deadline)
+	}
+	matched, elem, err := b.raceElems(sels, dur)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	// the first element is raced, re-get the specified one like GetElem
+	if sel := sels[matched]; sel.Nth != nil && *sel.Nth != 0 {
+		if elem = b.GetElemWithoutDelay(sel); elem == nil {
+			return -1, nil, ErrorSelNotFound
+		}
+	}
+	return owner[matched], elem, nil
+}
+
+// raceElems waits for sels at the same time, and returns the index of the first one to appear with its element
+func (b *Bot) raceElems(sels []Selector, dur time.Duration) (matched int, elem *rod.Element, err error) {
+	if len(sels) == 0 {
+		return -1, nil, ErrorSelNotFound
+	}
+
+	// zero timeout checks once without waiting, like GetElem
+	pg := b.pageOf(sels[0]).Sleeper(rod.NotFoundSleeper)
+	if dur > 0 {
+		pg = b.pageOf(sels[0]).Timeout(dur)
+	}
+
+	matched = -1
 	err = rod.Try(func() {
-		r := b.Pg.Timeout(b.mediumToSec).Race()
-		for _, s := range selectors {
-			b.appendToRace(s, &sel, r)
+		r := pg.Race()
+		for i, sel := range sels {
+			b.appendToRace(sel, i, &matched, r)
 		}
-		r.MustDo()
+		elem = r.MustDo()
 	})
 	return
 }
@@ -262,18 +400,23 @@ func (b *Bot) EnsureAnyElem(selectors ...string) (sel string, err error) {
 // appendToRace:
 // if directly add race.Element in EnsureAnyElem, will always return the
 // last of the selectors
-func (b *Bot) appendToRace(s string, sel *string, race *rod.RaceContext) {
-	if funk.Contains(s, SEP) {
-		ss := strings.Split(s, SEP)
-		txt := strings.Join(ss[1:], SEP)
-		race.ElementR(ss[0], txt).MustHandle(func(_ *rod.Element) {
-			*sel = s
-		})
-	} else {
-		race.Element(s).MustHandle(func(_ *rod.Element) {
-			*sel = s
+//
+// *matched is set to i when sel matches
+func (b *Bot) appendToRace(sel Selector, i int, matched *int, race *rod.RaceContext) {
+	if sel.isPlainCSS() {
+		race.Element(sel.CSS).MustHandle(func(_ *rod.Element) {
+			*matched = i
 		})
+		return
 	}
+
+	opts := sel.evalOptions(false)
+	if sel.Root != nil {
+		opts = opts.This(sel.Root.Object)
+	}
+	race.ElementByJS(opts).MustHandle(func(_ *rod.Element) {
+		*matched = i
+	})
 }
 
 func (b *Bot) MustEnsureAnyElem(selectors ...string) string {
@@ -293,7 +436,7 @@ func (b *Bot) MustEnsureUrlHas(s string, opts ...BotOptFunc) {
 }
 
 func (b *Bot) EnsureUrlHas(s string, opts ...BotOptFunc) (err error) {
-	opt := BotOpts{Timeout: MediumToSec}
+	opt := BotOpts{Timeout: toSec(b.mediumToSec)}
 	BindBotOpts(&opt, opts...)
 
 	script := fmt.Sprintf(`() => decodeURIComponent(window.location.href).includes("%s")`, s)
@@ -318,31 +461,42 @@ func (b *Bot) MustEval(script string) (res string) {
 	return res
 }
 
-func (b *Bot) MustFillBar(sel, text string, opts ...BotOptFunc) (txt string) {
+func (b *Bot) MustFillBar(sel interface{}, text string, opts ...BotOptFunc) (txt string) {
 	txt, err := b.FillBar(sel, text, opts...)
 	b.PanicIfErr(err)
 	return txt
 }
 
-func (b *Bot) FillBar(sel, text string, opts ...BotOptFunc) (txt string, err error) {
+func (b *Bot) FillBar(sel interface{}, text string, opts ...BotOptFunc) (txt string, err error) {
 	opt := BotOpts{Submit: false}
 	BindBotOpts(&opt, opts...)
 
 	// elem := b.Pg.Timeout(time.Second * b.ShortTo).MustElement(sel).CancelTimeout()
 	elem := b.GetElem(sel, opts...)
 	if elem == nil {
+		if e := b.ctxErr(); e != nil {
+			return "", e
+		}
 		return "", ErrorSelNotFound
 	}
 
 	b.CloseIfHasPopovers()
 	b.Highlight(elem)
 	// elem = elem.Timeout(time.Second * b.ShortTo).MustSelectAllText().MustInput(text)
-	elem = b.FillAsHuman(elem, text)
+	if err := b.FillAsHumanE(elem, text); err != nil {
+		return "", err
+	}
 	if opt.Submit {
-		xutil.RandSleep(0.1, 0.15)
-		// elem = elem.MustPress(input.Enter)
-		elem.MustKeyActions().Press(input.Enter).MustDo()
-		// return nil
+		if err := b.randSleep(0.1, 0.15); err != nil {
+			return "", err
+		}
+		ka, err := elem.KeyActions()
+		if err != nil {
+			return "", err
+		}
+		if err := ka.Press(input.Enter).Do(); err != nil {
+			return "", err
+		}
 	}
 	// just try to get text, won't matter if fails
 	txt, _ = elem.Text()
@@ -356,7 +510,20 @@ func (b *Bot) FillBar(sel, text string, opts ...BotOptFunc) (txt string, err err
 //
 //	@return *rod.Element
 func (b *Bot) FillAsHuman(elem *rod.Element, text string, args ...int) *rod.Element {
-	elem.MustSelectAllText().MustInput("")
+	b.PanicIfErr(b.FillAsHumanE(elem, text, args...))
+	return elem
+}
+
+// FillAsHumanE is same as FillAsHuman, but returns error instead of panic,
+// ctx's error is returned once bot's ctx is done
+func (b *Bot) FillAsHumanE(elem *rod.Element, text string, args ...int) error {
+	if err := elem.SelectAllText(); err != nil {
+		return err
+	}
+	if err := elem.Input(""); err != nil {
+		return err
+	}
+
 	n := xutil.FirstOrDefaultArgs(0, args...)
 	if n == 0 {
 		n = xutil.AorB(b.Config.PerInputLength, 5)
@@ -364,16 +531,19 @@ func (b *Bot) FillAsHuman(elem *rod.Element, text string, args ...int) *rod.Elem
 
 	arr := xutil.NewStringSlice(text, n, true)
 	for _, str := range arr {
-		e := elem.Input(str)
-		b.PanicIfErr(e)
+		if e := b.ctxErr(); e != nil {
+			return e
+		}
+		if e := elem.Input(str); e != nil {
+			return e
+		}
 	}
 
 	to := 0.1
 	if len(args) >= 2 {
 		to = cast.ToFloat64(args[1]) / 10
 	}
-	xutil.RandSleep(to-0.01, to+0.01)
-	return elem
+	return b.randSleep(to-0.01, to+0.01)
 }
 
 func (b *Bot) FillCharsOneByOne(elem *rod.Element, value string) {
@@ -384,9 +554,11 @@ func (b *Bot) FillCharsOneByOne(elem *rod.Element, value string) {
 //
 // get all elems if found by selectors
 func (b *Bot) MGetElems(selectors []string, opts ...BotOptFunc) (elems []*rod.Element) {
+	// each selector is waited once by GetElem, GetElems only reads with root, frame... of opts
+	noWait := append(append([]BotOptFunc{}, opts...), BotTimeout(0))
 	for _, sel := range selectors {
 		b.GetElem(sel, opts...)
-		e1 := b.GetElems(sel)
+		e1 := b.GetElems(sel, noWait...)
 		elems = append(elems, e1...)
 	}
 	return
@@ -394,12 +566,26 @@ func (b *Bot) MGetElems(selectors []string, opts ...BotOptFunc) (elems []*rod.El
 
 // MGetElemsAllAttr
 //
-// get all elems' attribute
+// get all elems' attribute, which are read in one evaluation by GetElemsAttrs
 func (b *Bot) MGetElemsAllAttr(selectors []string, opts ...BotOptFunc) []string {
-	var attrs []string
-	for _, elem := range b.MGetElems(selectors, opts...) {
-		at := b.getElemAttr(elem, opts...)
-		attrs = append(attrs, at)
+	opt := BotOpts{Attr: "innerText"}
+	BindBotOpts(&opt, opts...)
+	attr := xutil.AorB(opt.Attr, "innerText")
+
+	// wait for each selector as MGetElems does
+	for _, sel := range selectors {
+		b.GetElem(sel, opts...)
+	}
+
+	rows, err := b.GetElemsAttrs(selectors, []string{attr}, opts...)
+	if err != nil {
+		log.Error().Err(err).Strs("selectors", selectors).Msg("MGetElemsAllAttr")
+		return nil
+	}
+
+	attrs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		attrs = append(attrs, row[attr])
 	}
 	return attrs
 }
@@ -412,46 +598,68 @@ func (b *Bot) MGetElemsAllAttr(selectors []string, opts ...BotOptFunc) []string
 // and as the test results of `func (s *botSuite) TestGetElems()`
 // the whole GetElems' time cost is less than 0.2 second
 //
-// get all elements that match the css selector or []
-// just an alias of bot.Page.Elements
+// get all elements that match the selector (string or Selector) or [],
+// elements are filtered by text if selector has text,
+// the Nth of selector is ignored
 //
 // if you want handle the error info, please call b.Pg.Elements directly
-func (b *Bot) GetElems(selector string, opts ...BotOptFunc) (elems []*rod.Element) {
-	if selector == "" {
-		return
+func (b *Bot) GetElems(selector interface{}, opts ...BotOptFunc) (elems []*rod.Element) {
+	if chain, ok := b.toChain(selector); ok {
+		s, _, found := b.pickFromChain(chain, 0, opts...)
+		if !found {
+			return
+		}
+		selector = s
 	}
 
-	if strings.Contains(selector, SEP) {
-		log.Error().Str("selector", selector).Msgf("invalid format which contains %q", SEP)
+	sel, err := toSelector(selector)
+	if err != nil {
+		log.Error().Err(err).Msg("error of GetElems")
 		return
 	}
+	if sel.IsEmpty() {
+		return
+	}
+	sel.Nth = nil
 
 	opt := BotOpts{Timeout: 0}
 	BindBotOpts(&opt, opts...)
+	sel = opt.bindSelector(sel)
+	if sel, err = b.bindFrame(sel, &opt); err != nil {
+		log.Error().Err(err).Stringer("selector", sel).Msg("error of GetElems")
+		return
+	}
 	if opt.Timeout != 0 {
-		b.GetElem(selector, BotTimeout(opt.Timeout))
+		b.GetElem(sel, BotTimeout(opt.Timeout))
 	}
 
-	elems, err := b.Pg.Elements(selector)
+	elems, err = b.queryElems(sel)
 	if err != nil {
-		log.Error().Err(err).Str("selector", selector).Msg("error of GetElems")
+		log.Error().Err(err).Stringer("selector", sel).Msg("error of GetElems")
 	}
 
 	return elems
 }
 
-func (b *Bot) GetElemWithoutDelay(selector string, indexArgs ...int) *rod.Element {
-	index := xutil.FirstOrDefaultArgs(0, indexArgs...)
-	elems := b.GetElems(selector)
+func (b *Bot) GetElemWithoutDelay(selector interface{}, indexArgs ...int) *rod.Element {
+	sel, err := toSelector(selector)
+	if err != nil {
+		log.Error().Err(err).Msg("error of GetElemWithoutDelay")
+		return nil
+	}
+	if len(indexArgs) != 0 {
+		sel = sel.At(indexArgs[0])
+	}
 
+	elems := b.GetElems(sel)
 	if funk.IsEmpty(elems) {
 		return nil
 	}
 
-	if index < 0 {
-		index = len(elems) + index
+	index, ok := sel.index(len(elems))
+	if !ok {
+		index = xutil.Min(xutil.Max(index, 0), len(elems)-1)
 	}
-	index = xutil.Min(index, len(elems)-1)
 	return elems[index]
 }
 
@@ -459,12 +667,16 @@ func (b *Bot) GetElemWithoutDelay(selector string, indexArgs ...int) *rod.Elemen
 //
 // in most cases, this is not needed
 // for now, tested with popovers, when some site show popovers at a random time window
-func (b *Bot) GetElemUntilInteractable(selector string, opts ...BotOptFunc) (elem *rod.Element) {
+//
+// returns nil once bot's ctx is done
+func (b *Bot) GetElemUntilInteractable(selector interface{}, opts ...BotOptFunc) (elem *rod.Element) {
 	ts := time.Now()
 	for {
 		elem = b.GetElem(selector, opts...)
 		if elem == nil {
-			xutil.RandSleep(0.5, 1)
+			if err := b.randSleep(0.5, 1); err != nil {
+				return nil
+			}
 			continue
 		}
 
@@ -472,17 +684,21 @@ func (b *Bot) GetElemUntilInteractable(selector string, opts ...BotOptFunc) (ele
 			return
 		}
 
-		log.Warn().Bool("interactable", elem.MustInteractable()).Msgf("un-interactable of %q", selector)
-		xutil.RandSleep(0.5, 1)
+		log.Warn().Bool("interactable", elem.MustInteractable()).Msgf("un-interactable of %v", selector)
+		if err := b.randSleep(0.5, 1); err != nil {
+			return nil
+		}
 
 		cost := xutil.ElapsedSeconds(ts, 2)
-		if cost > MediumToSec {
+		if cost > b.mediumToSec.Seconds() {
 			return nil
 		}
 	}
 }
 
-// GetElem by default wait (MediumTo) for the element to appear and return it
+// GetElem by default wait (MediumTo) for the element to appear and return it,
+// selector is a string (see ParseSelector) or Selector, css and xpath are both supported,
+// element name of registry like "login.submit" and SelectorChain are also accepted, see UseRegistry/Fallback
 //
 // use cases:
 //  1. opt.Timeout == 0
@@ -494,84 +710,121 @@ func (b *Bot) GetElemUntilInteractable(selector string, opts ...BotOptFunc) (ele
 //     2.2 with index (support python style index: -1, ...)
 //     - wait the element for opt.Timeout
 //     - re-get elem by GetElemWithoutDelay
-//     2.3 WARN: if SEP(@@@) in selector
-//     - will return the elem found by ElementR
-//     - will skip the index passed in
-func (b *Bot) GetElem(selector string, opts ...BotOptFunc) (elem *rod.Element) {
-	if selector == "" {
+//
+// index is ElemIndex of opts or Nth of selector, text filters work the same in all cases
+func (b *Bot) GetElem(selector interface{}, opts ...BotOptFunc) (elem *rod.Element) {
+	if chain, ok := b.toChain(selector); ok {
+		opt := BotOpts{ElemIndex: xutil.MaxInt, Timeout: toSec(b.mediumToSec)}
+		BindBotOpts(&opt, opts...)
+		s, first, found := b.pickFromChain(chain, opt.Timeout, opts...)
+		if !found {
+			return nil
+		}
+		b.selector = s
+
+		// the chain has waited for the element, reuse it unless another one is wanted
+		if opt.Timeout != 0 && (opt.ElemIndex == xutil.MaxInt || opt.ElemIndex == 0) {
+			return first
+		}
+		selector = s
+		if opt.Timeout != 0 {
+			opts = append(opts[:len(opts):len(opts)], BotTimeout(0))
+		}
+	}
+
+	sel, err := toSelector(selector)
+	if err != nil {
+		log.Error().Err(err).Msg("error of GetElem")
+		return
+	}
+	if sel.IsEmpty() {
 		w, i := xpretty.Caller(2)
 		log.Warn().Str("file", w).Int("line", i).Msg("selector is empty")
 		return
 	}
 	b.selector = selector
 
-	byText := strings.Contains(selector, SEP)
-
-	opt := BotOpts{ElemIndex: xutil.MaxInt, Timeout: MediumToSec, root: b.root}
+	opt := BotOpts{ElemIndex: xutil.MaxInt, Timeout: toSec(b.mediumToSec), root: b.root}
 	BindBotOpts(&opt, opts...)
-
-	if !byText && int(opt.Timeout) == 0 {
-		return b.GetElemWithoutDelay(selector, opt.ElemIndex)
+	// frame resolution and the element wait share the deadline
+	deadline := time.Now().Add(time.Duration(opt.Timeout) * time.Second)
+	sel = opt.bindSelector(sel)
+	if sel, err = b.bindFrame(sel, &opt); err != nil {
+		log.Debug().Err(err).Stringer("selector", sel).Msg("GetElem")
+		return
+	}
+	if opt.ElemIndex != xutil.MaxInt {
+		sel = sel.At(opt.ElemIndex)
 	}
 
-	ts := time.Now()
-	// xutil.DumbLog(fmt.Sprintf("wait for (%s) to appear", selector))
-	// wait elem of selector to appear
-
-	dur := time.Duration(opt.Timeout) * time.Second
-	var err error
-	if funk.Contains(selector, SEP) {
-		ss := strutil.Split(selector, SEP)
-		txt := ss[len(ss)-1]
-		if len(ss) == 3 {
-			// when selector is like div.abc@@@---@@@txt, we use exactly match
-			m := "/^%s$/"
-			if opt.CaseInsensitive {
-				m += "i"
-			}
-			txt = fmt.Sprintf(m, txt)
-		}
-		if opt.root != nil {
-			elem, err = opt.root.Timeout(dur).ElementR(ss[0], txt)
-		} else {
-			elem, err = b.Pg.Timeout(dur).ElementR(ss[0], txt)
-		}
-	} else {
-		if opt.root != nil {
-			elem, err = opt.root.Timeout(dur).Element(selector)
-		} else {
-			elem, err = b.Pg.Timeout(dur).Element(selector)
+	if int(opt.Timeout) == 0 {
+		// without index, the last matched one is returned as before
+		if sel.Nth == nil {
+			sel = sel.At(xutil.MaxInt)
 		}
+		return b.GetElemWithoutDelay(sel)
 	}
 
+	ts := time.Now()
+	// wait elem of selector to appear
+	elem, err = b.waitElem(sel, remaining(deadline))
 	if err != nil {
 		return elem
 	}
 
-	nonMax := opt.ElemIndex != xutil.MaxInt
-	nonZero := opt.ElemIndex != 0
-	// if specify index not 0/max, and no SEP(@@@) in selector, re-get it by GetElems
+	// if specify index not 0, re-get it by GetElemWithoutDelay
 	// this is used when we first need to wait elems to appear, then get the specified one
-	if !byText && nonMax && nonZero {
-		elem = b.GetElemWithoutDelay(selector, opt.ElemIndex)
+	if sel.Nth != nil && *sel.Nth != 0 {
+		elem = b.GetElemWithoutDelay(sel)
+		if elem == nil {
+			return nil
+		}
 	}
 
 	if v := elem.MustInteractable(); !v {
-		log.Trace().Msgf("[GetElem] %s interactable=%t", selector, v)
+		log.Trace().Msgf("[GetElem] %s interactable=%t", sel, v)
 	}
 	cost := xutil.ElapsedSeconds(ts, 2)
 	if cost > 2.0 {
-		log.Debug().Float64("cost", cost).Str("selector", selector).Msg("GetElem")
+		log.Debug().Float64("cost", cost).Stringer("selector", sel).Msg("GetElem")
 	}
 
 	return elem
 }
 
+// waitElem waits for the first element of sel to appear
+func (b *Bot) waitElem(sel Selector, dur time.Duration) (*rod.Element, error) {
+	pg := b.pageOf(sel).Timeout(dur)
+	if sel.isPlainCSS() {
+		return pg.Element(sel.CSS)
+	}
+
+	opts := sel.evalOptions(false)
+	if sel.Root != nil {
+		opts = opts.This(sel.Root.Object)
+	}
+	return pg.ElementByJS(opts)
+}
+
+// queryElems returns all elements of sel without waiting
+func (b *Bot) queryElems(sel Selector) (rod.Elements, error) {
+	pg := b.pageOf(sel)
+	if sel.isPlainCSS() {
+		return pg.Elements(sel.CSS)
+	}
+
+	opts := sel.evalOptions(true)
+	if sel.Root != nil {
+		opts = opts.This(sel.Root.Object)
+	}
+	return pg.ElementsByJS(opts)
+}
+
 // GetElemWithRetry
 //
 // works with Panic, not error
-func (b *Bot) GetElemWithRetry(selector string, retryTimes int, opts ...BotOptFunc) (elem *rod.Element, err error) {
-	opt := BotOpts{Timeout: NapToSec}
+func (b *Bot) GetElemWithRetry(selector interface{}, retryTimes int, opts ...BotOptFunc) (elem *rod.Element, err error) {
+	opt := BotOpts{Timeout: toSec(b.NapToSec)}
 	BindBotOpts(&opt, opts...)
 
 	i, err := b.RetryWhenPanic(func() {
@@ -586,7 +839,7 @@ func (b *Bot) GetElemWithRetry(selector string, retryTimes int, opts ...BotOptFu
 	return
 }
 
-func (b *Bot) GetElementAttrByRetry(selector string, opts ...BotOptFunc) string {
+func (b *Bot) GetElementAttrByRetry(selector interface{}, opts ...BotOptFunc) string {
 	opt := BotOpts{
 		retry: 3,
 	}
@@ -609,8 +862,8 @@ func (b *Bot) GetElementAttrByRetry(selector string, opts ...BotOptFunc) string
 //
 // - will panic is selector is ""
 // - will return "" if no elem found by given selector
-func (b *Bot) GetElementAttr(selector string, opts ...BotOptFunc) string {
-	if selector == "" {
+func (b *Bot) GetElementAttr(selector interface{}, opts ...BotOptFunc) string {
+	if funk.IsEmpty(selector) {
 		panic("selector is empty")
 	}
 
@@ -652,7 +905,7 @@ func (b *Bot) getElemAttr(elem *rod.Element, opts ...BotOptFunc) (val string) {
 	return *s
 }
 
-func (b *Bot) GetElementProp(selector string, opts ...BotOptFunc) (string, error) {
+func (b *Bot) GetElementProp(selector interface{}, opts ...BotOptFunc) (string, error) {
 	opt := BotOpts{
 		ElemIndex: 0,
 		Property:  "value",
@@ -695,6 +948,9 @@ func (b *Bot) ScrollAndClick(selector interface{}, opts ...BotOptFunc) error {
 	}
 	elem := b.RecalculateElem(selector, opts...)
 	if elem == nil {
+		if e := b.ctxErr(); e != nil {
+			return e
+		}
 		return ErrorSelNotFound
 	}
 	return b.ScrollAndClickElem(elem)
@@ -702,7 +958,7 @@ func (b *Bot) ScrollAndClick(selector interface{}, opts ...BotOptFunc) error {
 
 func (b *Bot) ClickAndSwitchToNewPage(selector interface{}, opts ...BotOptFunc) (*rod.Page, error) {
 	opt := BotOpts{
-		Timeout: MediumToSec,
+		Timeout: toSec(b.mediumToSec),
 	}
 	BindBotOpts(&opt, opts...)
 
@@ -725,7 +981,7 @@ func (b *Bot) MustClickAndSwitchToNewPage(selector interface{}, opts ...BotOptFu
 
 func (b *Bot) MustClickAndSwitchToNewPageWithScript(selector interface{}, opts ...BotOptFunc) *rod.Page {
 	wait := b.Pg.WaitOpen()
-	elem := b.GetElem(selector.(string), opts...)
+	elem := b.RecalculateElem(selector, opts...)
 	_ = b.ClickWithScript(elem)
 	pg, err := wait()
 	b.UpdatePage(pg)
@@ -764,7 +1020,11 @@ func (b *Bot) ScrollAndClickElem(elem *rod.Element, retryArgs ...uint) error {
 			})
 		},
 		retry.Attempts(attempt),
+		retry.Context(b.Context()),
 	)
+	if e := b.ctxErr(); e != nil {
+		return e
+	}
 	return err
 }
 
@@ -970,11 +1230,11 @@ func (b *Bot) ScrollToBottom(opts ...BotOptFunc) error {
 	return e
 }
 
+// GetElemBox returns the box of elem relative to the top page, even if elem is inside iframes
 func (b *Bot) GetElemBox(elem interface{}) (box Box, err error) {
 	elem = b.RecalculateElem(elem)
-	rect := "() => JSON.stringify(this.getBoundingClientRect())"
 	err = rod.Try(func() {
-		dat := elem.(*rod.Element).Timeout(b.shortToSec).MustEval(rect).String()
+		dat := elem.(*rod.Element).Timeout(b.shortToSec).MustEval(elemBoxJS).String()
 		log.Trace().Msg(dat)
 		e := json.Unmarshal([]byte(dat), &box)
 		if e != nil {
@@ -988,7 +1248,7 @@ func (b *Bot) GetElemBox(elem interface{}) (box Box, err error) {
 // RecalculateElem automatically decide GetElem/GetElement by type of elem
 func (b *Bot) RecalculateElem(elem interface{}, opts ...BotOptFunc) (newElem *rod.Element) {
 	switch elem := elem.(type) {
-	case string:
+	case string, Selector, *Selector, SelectorChain, *SelectorChain:
 		newElem = b.GetElem(elem, opts...)
 	case *rod.Element:
 		newElem = elem
@@ -1015,7 +1275,7 @@ func (b *Bot) ScrollLikeHuman(offsetX, offsetY float64, opts ...BotOptFunc) erro
 
 	if !b.ScrollAsHuman.enabled || steps == 0 {
 		err := page.Mouse.Scroll(offsetX, offsetY, 1)
-		xutil.RandSleep(0.1, 0.2)
+		_ = b.randSleep(0.1, 0.2)
 		return err
 	}
 
@@ -1031,24 +1291,32 @@ func (b *Bot) ScrollLikeHuman(offsetX, offsetY float64, opts ...BotOptFunc) erro
 	startAt := time.Now()
 
 	for totalScrolled < totalNeeded {
+		if err := b.ctxErr(); err != nil {
+			return err
+		}
+
 		yNegative := false
 		// handle too slow scroll
 		cost := xutil.ElapsedSeconds(startAt, 2)
 		if cost > float64(tooSlowTimeoutSec) {
 			err := page.Mouse.Scroll(offsetX, totalNeeded-totalScrolled, 1)
-			xutil.RandSleep(0.1, 0.2)
+			_ = b.randSleep(0.1, 0.2)
 			return err
 		}
 
 		chance := rand.Float64()
 
 		if chance < b.ScrollAsHuman.longSleepChance {
-			xutil.RandSleep(0.5, 0.6)
+			if err := b.randSleep(0.5, 0.6); err != nil {
+				return err
+			}
 			continue
 		}
 
 		if chance < b.ScrollAsHuman.shortSleepChance {
-			xutil.RandSleep(0.25, 0.3)
+			if err := b.randSleep(0.25, 0.3); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -1104,32 +1372,14 @@ func (b *Bot) BindRoot(root *rod.Element) {
 }
 
 func (b *Bot) SetPageWithCookies(page *rod.Page, raw string) error {
-	var cookies []proto.NetworkCookie
+	var cookies []*proto.NetworkCookie
 
 	err := json.Unmarshal([]byte(raw), &cookies)
 	if err != nil {
 		return err
 	}
 
-	var nodes []*proto.NetworkCookieParam
-	for _, cookie := range cookies {
-		nodes = append(nodes, &proto.NetworkCookieParam{
-			Name:         cookie.Name,
-			Value:        cookie.Value,
-			Domain:       cookie.Domain,
-			Path:         cookie.Path,
-			Secure:       cookie.Secure,
-			HTTPOnly:     cookie.HTTPOnly,
-			SameSite:     cookie.SameSite,
-			Expires:      cookie.Expires,
-			Priority:     cookie.Priority,
-			SameParty:    cookie.SameParty,
-			SourceScheme: cookie.SourceScheme,
-			SourcePort:   &cookie.SourcePort,
-		})
-	}
-
-	return page.SetCookies(nodes)
+	return page.SetCookies(toCookieParams(cookies))
 }
 
 func (b *Bot) Close() {
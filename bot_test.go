@@ -783,11 +783,11 @@ var getElemData = []botTest{
 		index:   -1,
 	},
 	{
-		name: "with @@@ by text, should find 1 in GetElem/0 in GetElems",
+		name: "with @@@ by text, should find 1 in GetElem/GetElems",
 		args: &baseArgs{
 			sel: blocket.category,
 		},
-		want:    0,
+		want:    1,
 		wantInt: 1,
 		to:      5,
 		index:   0,
@@ -0,0 +1,222 @@
+package xbot
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coghost/xutil"
+	"github.com/go-rod/rod"
+	"github.com/rs/zerolog/log"
+)
+
+// SelectorChain is a logical element with ordered fallback selectors, the first one is the primary.
+// GetElem/GetElems accept it, and record which selector matched to SelectorTelemetry
+type SelectorChain struct {
+	// Name identifies the element in report, the primary selector is used if empty
+	Name      string
+	Selectors []string
+	// Race takes the first selector to appear like EnsureAnyElem,
+	// otherwise earlier selectors are preferred when several are present
+	Race bool
+}
+
+// Fallback creates a chain of primary and its fallbacks
+func Fallback(primary string, fallbacks ...string) SelectorChain {
+	return SelectorChain{Selectors: append([]string{primary}, fallbacks...)}
+}
+
+// Named returns a copy of c with name
+func (c SelectorChain) Named(name string) SelectorChain {
+	c.Name = name
+	return c
+}
+
+// Racing returns a copy of c which races its selectors
+func (c SelectorChain) Racing() SelectorChain {
+	c.Race = true
+	return c
+}
+
+func (c SelectorChain) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if len(c.Selectors) != 0 {
+		return c.Selectors[0]
+	}
+	return ""
+}
+
+// SelectorHealth is the lookup stats of a chain
+type SelectorHealth struct {
+	Name    string
+	Primary string
+	Lookups int
+	// PrimaryMisses counts lookups the primary selector didn't match
+	PrimaryMisses int
+	// Fallbacks counts lookups matched by each fallback selector
+	Fallbacks map[string]int
+	// Misses counts lookups no selector matched
+	Misses int
+	// LastFallback is the time a fallback selector or nothing matched
+	LastFallback time.Time
+}
+
+// PrimaryFailing reports whether the primary selector has missed
+func (h SelectorHealth) PrimaryFailing() bool {
+	return h.PrimaryMisses != 0
+}
+
+// SelectorTelemetry records lookups of chains, it's safe for concurrent use,
+// so it can be shared by bots with WithSelectorTelemetry
+type SelectorTelemetry struct {
+	mu    sync.Mutex
+	stats map[string]*SelectorHealth
+}
+
+func NewSelectorTelemetry() *SelectorTelemetry {
+	return &SelectorTelemetry{stats: make(map[string]*SelectorHealth)}
+}
+
+// record records the lookup of chain, matched is the index of matched selector, -1 if none
+func (t *SelectorTelemetry) record(chain SelectorChain, matched int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name := chain.name()
+	h, ok := t.stats[name]
+	if !ok {
+		h = &SelectorHealth{Name: name, Primary: chain.Selectors[0], Fallbacks: make(map[string]int)}
+		t.stats[name] = h
+	}
+
+	h.Lookups++
+	if matched == 0 {
+		return
+	}
+
+	h.PrimaryMisses++
+	h.LastFallback = time.Now()
+	if matched < 0 {
+		h.Misses++
+		log.Warn().Str("element", name).Strs("selectors", chain.Selectors).Msg("all selectors failed")
+		return
+	}
+
+	h.Fallbacks[chain.Selectors[matched]]++
+	log.Warn().Str("element", name).Str("primary", h.Primary).Str("fallback", chain.Selectors[matched]).
+		Msg("primary selector failed, fallback used")
+}
+
+// Stats returns stats of all chains sorted by name
+func (t *SelectorTelemetry) Stats() []SelectorHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	all := make([]SelectorHealth, 0, len(t.stats))
+	for _, h := range t.stats {
+		cp := *h
+		cp.Fallbacks = make(map[string]int, len(h.Fallbacks))
+		for k, v := range h.Fallbacks {
+			cp.Fallbacks[k] = v
+		}
+		all = append(all, cp)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Report returns chains whose primary selector is failing, the most failing first
+func (t *SelectorTelemetry) Report() (failing []SelectorHealth) {
+	for _, h := range t.Stats() {
+		if h.PrimaryFailing() {
+			failing = append(failing, h)
+		}
+	}
+	sort.SliceStable(failing, func(i, j int) bool { return failing[i].PrimaryMisses > failing[j].PrimaryMisses })
+	return
+}
+
+// SelectorTelemetry returns the telemetry which records lookups of bot's chains
+func (b *Bot) SelectorTelemetry() *SelectorTelemetry {
+	if b.telemetry == nil {
+		b.telemetry = NewSelectorTelemetry()
+	}
+	return b.telemetry
+}
+
+// SelectorReport is a shortcut of b.SelectorTelemetry().Report()
+func (b *Bot) SelectorReport() []SelectorHealth {
+	return b.SelectorTelemetry().Report()
+}
+
+// toChain returns the chain if v is a SelectorChain or an element name of bot's registry
+func (b *Bot) toChain(v interface{}) (SelectorChain, bool) {
+	switch v := v.(type) {
+	case SelectorChain:
+		return v, len(v.Selectors) != 0
+	case *SelectorChain:
+		if v != nil {
+			return *v, len(v.Selectors) != 0
+		}
+		return SelectorChain{}, false
+	}
+
+	return b.lookupName(v)
+}
+
+// pickFromChain returns the selector of chain which has elements with its first element, waits up to timeout seconds
+func (b *Bot) pickFromChain(chain SelectorChain, timeout int, opts ...BotOptFunc) (string, *rod.Element, bool) {
+	var (
+		matched int
+		elem    *rod.Element
+	)
+	if chain.Race && timeout != 0 {
+		matched, elem = b.raceChain(chain, timeout, opts...)
+	} else {
+		matched, elem = b.pollChain(chain, timeout, opts...)
+	}
+
+	b.SelectorTelemetry().record(chain, matched)
+	if matched < 0 {
+		return "", nil, false
+	}
+	return chain.Selectors[matched], elem, true
+}
+
+// pollChain checks selectors in order until one has elements or timeout
+func (b *Bot) pollChain(chain SelectorChain, timeout int, opts ...BotOptFunc) (int, *rod.Element) {
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	noWait := append(opts[:len(opts):len(opts)], BotTimeout(0))
+	for {
+		for i, s := range chain.Selectors {
+			if elems := b.GetElems(s, noWait...); len(elems) != 0 {
+				return i, elems[0]
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			return -1, nil
+		}
+		if err := b.randSleep(0.2, 0.3); err != nil {
+			return -1, nil
+		}
+	}
+}
+
+// raceChain waits for all selectors at the same time, and returns the first one to appear with its element,
+// opts like WithRoot/WithFrame are applied, but the index is left to the caller
+func (b *Bot) raceChain(chain SelectorChain, timeout int, opts ...BotOptFunc) (int, *rod.Element) {
+	sels := make([]interface{}, 0, len(chain.Selectors))
+	for _, s := range chain.Selectors {
+		sels = append(sels, s)
+	}
+
+	opts = append(opts[:len(opts):len(opts)], BotTimeout(timeout), ElemIndex(xutil.MaxInt))
+	matched, elem, err := b.WaitAnyElem(sels, opts...)
+	if err != nil {
+		return -1, nil
+	}
+	return matched, elem
+}
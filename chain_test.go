@@ -0,0 +1,83 @@
+package xbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ChainSuite struct {
+	suite.Suite
+}
+
+func TestChain(t *testing.T) {
+	suite.Run(t, new(ChainSuite))
+}
+
+func (s *ChainSuite) Test_01_Chain() {
+	c := Fallback("button#submit", "button[type=submit]")
+	s.Equal("button#submit", c.name())
+	s.False(c.Race)
+
+	c = c.Named("login.submit").Racing()
+	s.Equal("login.submit", c.name())
+	s.True(c.Race)
+
+	b := &Bot{}
+	got, ok := b.toChain(c)
+	s.True(ok)
+	s.Equal(c, got)
+
+	_, ok = b.toChain(&c)
+	s.True(ok)
+
+	for _, v := range []interface{}{"button", SelectorChain{}, (*SelectorChain)(nil), Sel("a")} {
+		_, ok = b.toChain(v)
+		s.False(ok, v)
+	}
+}
+
+func (s *ChainSuite) Test_02_Telemetry() {
+	t := NewSelectorTelemetry()
+	login := Fallback("#a", "#b", "#c").Named("login")
+	search := Fallback("#q")
+
+	t.record(login, 0)
+	t.record(login, 1)
+	t.record(login, 2)
+	t.record(login, 2)
+	t.record(login, -1)
+	t.record(search, 0)
+
+	stats := t.Stats()
+	s.Len(stats, 2)
+	s.Equal("#q", stats[0].Name)
+	s.False(stats[0].PrimaryFailing())
+
+	report := t.Report()
+	s.Len(report, 1)
+	h := report[0]
+	s.Equal("login", h.Name)
+	s.Equal("#a", h.Primary)
+	s.Equal(5, h.Lookups)
+	s.Equal(4, h.PrimaryMisses)
+	s.Equal(1, h.Misses)
+	s.Equal(map[string]int{"#b": 1, "#c": 2}, h.Fallbacks)
+	s.False(h.LastFallback.IsZero())
+
+	// stats are copies
+	h.Fallbacks["#b"] = 100
+	s.Equal(1, t.Report()[0].Fallbacks["#b"])
+}
+
+func (s *ChainSuite) Test_03_BotTelemetry() {
+	shared := NewSelectorTelemetry()
+	opt := BotOpts{}
+	BindBotOpts(&opt, WithSelectorTelemetry(shared))
+	s.Equal(shared, opt.telemetry)
+
+	b := &Bot{}
+	s.NotNil(b.SelectorTelemetry())
+	s.Equal(b.SelectorTelemetry(), b.SelectorTelemetry())
+	s.Empty(b.SelectorReport())
+}
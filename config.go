@@ -0,0 +1,235 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cast"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables which override BotConfig,
+// the name is the upper-cased `ini` tag, e.g. XBOT_HEADLESS=true, XBOT_PROXY_SERVER=127.0.0.1:1080
+const EnvPrefix = "XBOT_"
+
+// remoteServiceUrl is unexported, so it cannot be set by reflection
+const remoteServiceKey = "remote_service_url"
+
+var (
+	ErrorUnsupportedConfig = errors.New("unsupported config file format")
+	// ErrorUnknownConfigKey is returned for keys which are not `ini` tags of BotConfig, mostly typos
+	ErrorUnknownConfigKey = errors.New("unknown config key")
+)
+
+type ConfigSource string
+
+const (
+	SourceDefault ConfigSource = "default"
+	SourceFile    ConfigSource = "file"
+	SourceEnv     ConfigSource = "env"
+	SourceOption  ConfigSource = "option"
+)
+
+// ConfigReport records which source set each value, keyed by the `ini` tag of BotConfig
+type ConfigReport map[string]ConfigSource
+
+// Keys returns all keys set by the given source in sorted order
+func (r ConfigReport) Keys(src ConfigSource) (keys []string) {
+	for k, v := range r {
+		if v == src {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return
+}
+
+// LoadBotConfig loads BotConfig with layers:
+//
+//	NewDefaultBotCfg -> file (ini/yaml/toml by extension) -> XBOT_* env -> opts
+//
+// the keys in file are the `ini` tags of BotConfig, e.g. `user_agent`, `proxy_line`,
+// unknown keys are rejected with ErrorUnknownConfigKey, an empty path skips the file layer.
+func LoadBotConfig(path string, opts ...BotOptFunc) (*BotConfig, ConfigReport, error) {
+	cfg := NewDefaultBotCfg()
+	report := ConfigReport{}
+
+	for _, key := range configKeys() {
+		report[key] = SourceDefault
+	}
+
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := applyConfigValues(cfg, values, SourceFile, report); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := applyConfigValues(cfg, readConfigEnv(), SourceEnv, report); err != nil {
+		return nil, nil, fmt.Errorf("env: %w", err)
+	}
+
+	if len(opts) == 0 {
+		return cfg, report, nil
+	}
+
+	before := *cfg
+	opt := BotOpts{BotCfg: cfg}
+	BindBotOpts(&opt, opts...)
+	for _, key := range diffConfigKeys(&before, opt.BotCfg) {
+		report[key] = SourceOption
+	}
+
+	return opt.BotCfg, report, nil
+}
+
+func readConfigFile(path string) (map[string]interface{}, error) {
+	raw, err := os.ReadFile(expandPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ini", ".conf", ".cfg":
+		f, e := ini.Load(raw)
+		if e != nil {
+			return nil, e
+		}
+		for _, k := range f.Section(ini.DefaultSection).Keys() {
+			values[k.Name()] = k.Value()
+		}
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &values)
+	case ".toml":
+		err = toml.Unmarshal(raw, &values)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrorUnsupportedConfig, path)
+	}
+
+	return values, err
+}
+
+func readConfigEnv() map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, key := range configKeys() {
+		if v, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(key)); ok && v != "" {
+			values[key] = v
+		}
+	}
+	return values
+}
+
+func applyConfigValues(cfg *BotConfig, values map[string]interface{}, src ConfigSource, report ConfigReport) error {
+	fields := configFields()
+
+	var unknown []string
+	for key := range values {
+		if _, ok := fields[key]; !ok && key != remoteServiceKey {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: %s", ErrorUnknownConfigKey, strings.Join(unknown, ", "))
+	}
+
+	for key, val := range values {
+		if key == remoteServiceKey {
+			s, err := cast.ToStringE(val)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			cfg.remoteServiceUrl = s
+			report[key] = src
+			continue
+		}
+
+		if err := setConfigField(reflect.ValueOf(cfg).Elem().Field(fields[key]), val); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		report[key] = src
+	}
+	return nil
+}
+
+func setConfigField(field reflect.Value, val interface{}) error {
+	switch field.Kind() {
+	case reflect.String:
+		v, err := cast.ToStringE(val)
+		if err != nil {
+			return err
+		}
+		field.SetString(v)
+	case reflect.Bool:
+		v, err := cast.ToBoolE(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.Int:
+		v, err := cast.ToIntE(val)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(v))
+	case reflect.Float64:
+		v, err := cast.ToFloat64E(val)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	default:
+		return fmt.Errorf("unsupported kind %s", field.Kind())
+	}
+	return nil
+}
+
+// configFields maps the `ini` tag to the index of exported BotConfig field
+func configFields() map[string]int {
+	fields := make(map[string]int)
+	tp := reflect.TypeOf(BotConfig{})
+	for i := 0; i < tp.NumField(); i++ {
+		f := tp.Field(i)
+		tag := f.Tag.Get("ini")
+		if tag == "" || !f.IsExported() {
+			continue
+		}
+		fields[tag] = i
+	}
+	return fields
+}
+
+func configKeys() []string {
+	var keys []string
+	for k := range configFields() {
+		keys = append(keys, k)
+	}
+	keys = append(keys, remoteServiceKey)
+	sort.Strings(keys)
+	return keys
+}
+
+func diffConfigKeys(a, b *BotConfig) (keys []string) {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for key, i := range configFields() {
+		if va.Field(i).Interface() != vb.Field(i).Interface() {
+			keys = append(keys, key)
+		}
+	}
+	if a.remoteServiceUrl != b.remoteServiceUrl {
+		keys = append(keys, remoteServiceKey)
+	}
+	sort.Strings(keys)
+	return
+}
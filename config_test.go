@@ -0,0 +1,105 @@
+package xbot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigSuite struct {
+	suite.Suite
+	dir string
+}
+
+func TestConfig(t *testing.T) {
+	suite.Run(t, new(ConfigSuite))
+}
+
+func (s *ConfigSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+}
+
+func (s *ConfigSuite) write(name, content string) string {
+	p := filepath.Join(s.dir, name)
+	s.Nil(os.WriteFile(p, []byte(content), 0o600))
+	return p
+}
+
+func (s *ConfigSuite) Test_01_Formats() {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name:    "ini",
+			file:    "bot.ini",
+			content: "headless = true\nwidth = 1024\ntop = 30\nproxy_server = 127.0.0.1:1080\nremote_service_url = ws://127.0.0.1:7317\n",
+		},
+		{
+			name:    "yaml",
+			file:    "bot.yaml",
+			content: "headless: true\nwidth: 1024\ntop: 30\nproxy_server: 127.0.0.1:1080\nremote_service_url: ws://127.0.0.1:7317\n",
+		},
+		{
+			name:    "toml",
+			file:    "bot.toml",
+			content: "headless = true\nwidth = 1024\ntop = 30\nproxy_server = \"127.0.0.1:1080\"\nremote_service_url = \"ws://127.0.0.1:7317\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		cfg, report, err := LoadBotConfig(s.write(tt.file, tt.content))
+		s.Nil(err, tt.name)
+		s.True(cfg.Headless, tt.name)
+		s.Equal(1024, cfg.Width, tt.name)
+		s.Equal(30, cfg.Top, tt.name)
+		s.Equal("127.0.0.1:1080", cfg.ProxyServer, tt.name)
+		s.Equal("ws://127.0.0.1:7317", cfg.remoteServiceUrl, tt.name)
+		// untouched values keep defaults
+		s.Equal(728, cfg.Height, tt.name)
+
+		s.Equal(SourceFile, report["top"], tt.name)
+		s.Equal(SourceDefault, report["height"], tt.name)
+		s.Equal([]string{"headless", "proxy_server", "remote_service_url", "top", "width"}, report.Keys(SourceFile), tt.name)
+	}
+}
+
+func (s *ConfigSuite) Test_02_Layers() {
+	p := s.write("bot.yaml", "width: 1024\nheight: 600\nuser_agent: from-file\n")
+	s.T().Setenv("XBOT_HEIGHT", "500")
+	s.T().Setenv("XBOT_USER_AGENT", "from-env")
+
+	cfg, report, err := LoadBotConfig(p, BotUserAgent("from-option"))
+	s.Nil(err)
+
+	s.Equal(1024, cfg.Width)
+	s.Equal(500, cfg.Height)
+	s.Equal("from-option", cfg.UserAgent)
+
+	s.Equal(SourceFile, report["width"])
+	s.Equal(SourceEnv, report["height"])
+	s.Equal(SourceOption, report["user_agent"])
+	s.Equal(SourceDefault, report["steps"])
+}
+
+func (s *ConfigSuite) Test_03_Errors() {
+	_, _, err := LoadBotConfig(s.write("bot.json", "{}"))
+	s.ErrorIs(err, ErrorUnsupportedConfig)
+
+	_, _, err = LoadBotConfig(filepath.Join(s.dir, "non-exist.ini"))
+	s.ErrorIs(err, os.ErrNotExist)
+
+	_, _, err = LoadBotConfig(s.write("bot.ini", "width = wide\n"))
+	s.ErrorContains(err, "width")
+
+	_, _, err = LoadBotConfig(s.write("bot.yaml", "width: 1024\nheadles: true\nuser-agent: ua\n"))
+	s.ErrorIs(err, ErrorUnknownConfigKey)
+	s.ErrorContains(err, "headles, user-agent")
+
+	s.T().Setenv("XBOT_HEADLESS", "maybe")
+	_, _, err = LoadBotConfig("")
+	s.ErrorContains(err, "headless")
+}
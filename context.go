@@ -0,0 +1,67 @@
+package xbot
+
+import (
+	"context"
+	"time"
+
+	"github.com/coghost/xutil"
+)
+
+// WithContext returns a shallow copy of bot bound with ctx,
+// all page/element operations of the copy honour ctx's cancellation and deadline,
+// so a stuck crawl can be aborted by cancel the ctx.
+//
+// WARN: the copy shares browser and pages with the original bot,
+// but page switching (UpdatePage/ResetToOriginalPage) only affects the copy
+func (b *Bot) WithContext(ctx context.Context) *Bot {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	nb := *b
+	nb.ctx = ctx
+
+	if b.Brw != nil {
+		nb.Brw = b.Brw.Context(ctx)
+	}
+	if b.Pg != nil {
+		nb.Pg = b.Pg.Context(ctx)
+	}
+	if b.Iframe != nil {
+		nb.Iframe = b.Iframe.Context(ctx)
+	}
+	if b.PrevPage != nil {
+		nb.PrevPage = b.PrevPage.Context(ctx)
+	}
+	if b.root != nil {
+		nb.root = b.root.Context(ctx)
+	}
+
+	return &nb
+}
+
+// Context returns the ctx bound by WithContext, or context.Background if not bound
+func (b *Bot) Context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+// ctxErr returns non-nil error when bot's ctx is cancelled or deadline exceeded
+func (b *Bot) ctxErr() error {
+	return b.Context().Err()
+}
+
+// randSleep is same as xutil.RandSleep, but returns ctx's error as soon as bot's ctx is done
+func (b *Bot) randSleep(min, max float64) error {
+	timer := time.NewTimer(time.Duration(xutil.RandFloatX1k(min, max)) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case <-b.Context().Done():
+		return b.ctxErr()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package xbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ContextSuite struct {
+	suite.Suite
+}
+
+func TestContext(t *testing.T) {
+	suite.Run(t, new(ContextSuite))
+}
+
+func (s *ContextSuite) Test_01_WithContext() {
+	b := &Bot{}
+	s.Equal(context.Background(), b.Context())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	nb := b.WithContext(ctx)
+	s.Equal(ctx, nb.Context())
+	s.Nil(b.ctx, "original bot should not be changed")
+	s.Nil(nb.ctxErr())
+
+	cancel()
+	s.ErrorIs(nb.ctxErr(), context.Canceled)
+	s.Nil(b.ctxErr())
+}
+
+func (s *ContextSuite) Test_02_RandSleep() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	b := (&Bot{}).WithContext(ctx)
+
+	ts := time.Now()
+	err := b.randSleep(2, 3)
+	s.ErrorIs(err, context.DeadlineExceeded)
+	s.Less(time.Since(ts), time.Second)
+}
+
+func (s *ContextSuite) Test_03_RetryWhenPanic() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := (&Bot{}).WithContext(ctx)
+
+	calls := 0
+	tried, err := b.RetryWhenPanic(func() {
+		calls++
+		cancel()
+		panic("always fail")
+	}, 5)
+
+	s.ErrorIs(err, context.Canceled)
+	s.Equal(1, calls, "should stop retrying once ctx is cancelled")
+	s.Equal(1, tried)
+}
+
+func (s *ContextSuite) Test_04_RetryDelay() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	b := (&Bot{}).WithContext(ctx)
+
+	ts := time.Now()
+	tried, err := b.RetryWhenPanic(func() {
+		panic("always fail")
+	}, 3, 5000)
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+	s.Equal(1, tried)
+	s.Less(time.Since(ts), time.Second, "delay between tries should stop once ctx is done")
+}
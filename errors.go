@@ -0,0 +1,54 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrorLaunchFailed browser process cannot be started
+	ErrorLaunchFailed = errors.New("launch browser failed")
+	// ErrorProfileLocked user-mode browser is already running with the same profile
+	ErrorProfileLocked = errors.New("browser profile is locked by an existing session")
+	// ErrorConnectFailed cannot connect to the launched or remote browser
+	ErrorConnectFailed = errors.New("connect browser failed")
+	// ErrorPageCreationFailed cannot create or customize the page
+	ErrorPageCreationFailed = errors.New("create page failed")
+
+	ErrorUserAgentRequired = errors.New(`UserAgent is required, please use xbot.BotUserAgent(ua) to bind it;
+and you can visit https://www.whatismyip.com/user-agent/ to check your user-agent`)
+)
+
+// BotError wraps the original error with one of the ErrorXxx kinds above,
+// so callers can check it with `errors.Is(err, xbot.ErrorProfileLocked)`
+// and still get the original error by errors.Unwrap
+type BotError struct {
+	Kind error
+	Err  error
+}
+
+func newBotError(kind, err error) *BotError {
+	return &BotError{Kind: kind, Err: err}
+}
+
+func (e *BotError) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+}
+
+func (e *BotError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BotError) Is(target error) bool {
+	return e.Kind == target
+}
+
+const profileLockedMsg = "Opening in existing browser session"
+
+func isProfileLocked(err error) bool {
+	return err != nil && strings.Contains(err.Error(), profileLockedMsg)
+}
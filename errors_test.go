@@ -0,0 +1,34 @@
+package xbot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ErrorsSuite struct {
+	suite.Suite
+}
+
+func TestErrors(t *testing.T) {
+	suite.Run(t, new(ErrorsSuite))
+}
+
+func (s *ErrorsSuite) Test_01_BotError() {
+	raw := errors.New("[launcher] Failed to get the debug url: Opening in existing browser session.")
+	err := newBotError(ErrorProfileLocked, raw)
+
+	s.ErrorIs(err, ErrorProfileLocked)
+	s.ErrorIs(err, raw)
+	s.NotErrorIs(err, ErrorLaunchFailed)
+	s.Equal(raw, errors.Unwrap(err))
+	s.Contains(err.Error(), ErrorProfileLocked.Error())
+	s.Contains(err.Error(), raw.Error())
+}
+
+func (s *ErrorsSuite) Test_02_isProfileLocked() {
+	s.True(isProfileLocked(errors.New("Opening in existing browser session.")))
+	s.False(isProfileLocked(errors.New("exec: not found")))
+	s.False(isProfileLocked(nil))
+}
@@ -0,0 +1,488 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/spf13/cast"
+)
+
+// ExtractTag is the struct tag read by Bot.Extract
+const ExtractTag = "xbot"
+
+var ErrorExtract = errors.New("extract failed")
+
+// ExtractError reports the fields failed to extract, keyed by field path like "Items[2].Price"
+type ExtractError struct {
+	Fields map[string]error
+}
+
+func (e *ExtractError) Error() string {
+	paths := make([]string, 0, len(e.Fields))
+	for p := range e.Fields {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	msgs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", p, e.Fields[p]))
+	}
+	return fmt.Sprintf("%v: %s", ErrorExtract, strings.Join(msgs, "; "))
+}
+
+func (e *ExtractError) Is(target error) bool {
+	return target == ErrorExtract
+}
+
+// fieldSpec is parsed from tag like `xbot:"css=.price;attr=data-value;trim;regex=\\d+;default=0"`:
+//   - css/xpath: the element of field within the scope, the scope itself if both are empty,
+//     slice fields take all matched elements
+//   - attr: attribute to read, innerText by default, and innerHTML/outerHTML/textContent are supported
+//   - prop: property to read instead of attribute, e.g. prop=href for the absolute url
+//   - trim: trim spaces
+//   - regex: take the first group (or the whole match) of the go regex
+//   - default: used when element/attribute is missing or regex doesn't match
+//   - required: report error when element/attribute is missing
+//
+// struct tags are go strings, so write `\\d` for `\d` in regex, and `;;` for ";" in values
+type fieldSpec struct {
+	index    int
+	name     string
+	css      string
+	xpath    string
+	attr     string
+	prop     string
+	trim     bool
+	regex    *regexp.Regexp
+	def      *string
+	required bool
+
+	// all is true for slice fields
+	all bool
+	// sub is the plan of struct (or slice of struct) fields
+	sub *extractPlan
+}
+
+type extractPlan struct {
+	fields []*fieldSpec
+}
+
+// planField is sent to js, see extractJS
+type planField struct {
+	Name  string       `json:"name"`
+	CSS   string       `json:"css"`
+	XPath string       `json:"xpath"`
+	Attr  string       `json:"attr"`
+	Prop  string       `json:"prop"`
+	All   bool         `json:"all"`
+	Sub   []*planField `json:"sub"`
+}
+
+func (p *extractPlan) js() []*planField {
+	out := make([]*planField, 0, len(p.fields))
+	for _, f := range p.fields {
+		pf := &planField{Name: f.name, CSS: f.css, XPath: f.xpath, Attr: f.attr, Prop: f.prop, All: f.all}
+		if f.sub != nil {
+			pf.Sub = f.sub.js()
+		}
+		out = append(out, pf)
+	}
+	return out
+}
+
+// readValueJS reads property if prop is set, otherwise the attribute, null if missing
+const readValueJS = `function(e, attr, prop) {
+	if (prop) return e[prop] === undefined || e[prop] === null ? null : String(e[prop])
+	switch (attr) {
+	case "":
+	case "innerText":
+		return e.innerText === undefined ? e.textContent : e.innerText
+	case "innerHTML":
+	case "outerHTML":
+	case "textContent":
+		return e[attr]
+	}
+	return e.getAttribute ? e.getAttribute(attr) : null
+}`
+
+const extractJS = `function(plan, ...roots) {
+	const query = (scope, f) => {
+		if (!f.css && !f.xpath) return [scope]
+		return (` + queryNodesJS + `).call(scope, f.css, f.xpath)
+	}
+	const read = (e, f) => (` + readValueJS + `)(e, f.attr, f.prop)
+	const extract = (scope, fields) => {
+		const out = {}
+		for (const f of fields) {
+			const vals = query(scope, f).map((e) => f.sub ? extract(e, f.sub) : read(e, f))
+			out[f.name] = f.all ? vals : (vals.length ? vals[0] : null)
+		}
+		return out
+	}
+	return (roots.length ? roots : [document]).map((r) => extract(r, plan))
+}`
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	planCache sync.Map
+)
+
+// Extract fills dst from the elements, all fields are read in one round-trip
+//
+//   - dst is a pointer to struct, which is filled from root
+//   - dst is a pointer to slice of struct, each item is filled from each element of root
+//
+// root is nil (the document), *rod.Element, rod.Elements, or a selector accepted by GetElem/GetElems,
+// which is waited (MediumTo) for its first element.
+// struct fields can be nested structs, slices of structs or slices of scalars, see fieldSpec for the tag.
+//
+// conversion failures are reported as *ExtractError, the other fields are still filled
+func (b *Bot) Extract(root interface{}, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: dst should be a non-nil pointer, got %T", ErrorExtract, dst)
+	}
+
+	target := rv.Elem()
+	isList := target.Kind() == reflect.Slice
+	itemType := target.Type()
+	if isList {
+		itemType = itemType.Elem()
+	}
+	if itemType.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: dst should point to struct or slice of struct, got %T", ErrorExtract, dst)
+	}
+
+	plan, err := planOf(itemType)
+	if err != nil {
+		return err
+	}
+
+	roots, err := b.extractRoots(root, isList)
+	if err != nil {
+		return err
+	}
+	if isList && len(roots) == 0 {
+		target.Set(reflect.MakeSlice(target.Type(), 0, 0))
+		return nil
+	}
+
+	pg := b.pageOf(Selector{frame: b.Iframe})
+	args := []interface{}{plan.js()}
+	for _, r := range roots {
+		pg = r.Page()
+		args = append(args, r.Object)
+	}
+
+	obj, err := pg.Timeout(b.mediumToSec).Eval(extractJS, args...)
+	if err != nil {
+		return err
+	}
+
+	var raws []map[string]interface{}
+	if err := obj.Value.Unmarshal(&raws); err != nil {
+		return err
+	}
+
+	errs := map[string]error{}
+	if isList {
+		list := reflect.MakeSlice(target.Type(), len(raws), len(raws))
+		for i, raw := range raws {
+			plan.fill(list.Index(i), raw, fmt.Sprintf("[%d]", i), errs)
+		}
+		target.Set(list)
+	} else if len(raws) != 0 {
+		plan.fill(target, raws[0], "", errs)
+	}
+
+	if len(errs) != 0 {
+		return &ExtractError{Fields: errs}
+	}
+	return nil
+}
+
+func (b *Bot) MustExtract(root interface{}, dst interface{}) {
+	err := b.Extract(root, dst)
+	b.PanicIfErr(err)
+}
+
+func (b *Bot) extractRoots(root interface{}, isList bool) ([]*rod.Element, error) {
+	switch root := root.(type) {
+	case nil:
+		if isList {
+			return nil, fmt.Errorf("%w: root of list is required", ErrorExtract)
+		}
+		return nil, nil
+	case *rod.Element:
+		return []*rod.Element{root}, nil
+	case rod.Elements:
+		return root, nil
+	case []*rod.Element:
+		return root, nil
+	}
+
+	if isList {
+		// wait for the list to render like a single root, so a slow list isn't extracted as empty
+		b.GetElem(root)
+		return b.GetElems(root), nil
+	}
+
+	elem := b.GetElem(root)
+	if elem == nil {
+		return nil, fmt.Errorf("%w: %v", ErrorSelNotFound, root)
+	}
+	return []*rod.Element{elem}, nil
+}
+
+// planOf parses the tags of struct type t, plans are cached by type
+func planOf(t reflect.Type) (*extractPlan, error) {
+	if p, ok := planCache.Load(t); ok {
+		return p.(*extractPlan), nil
+	}
+
+	p, err := buildPlan(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	planCache.Store(t, p)
+	return p, nil
+}
+
+func buildPlan(t reflect.Type, visiting map[reflect.Type]bool) (*extractPlan, error) {
+	if visiting[t] {
+		return nil, fmt.Errorf("%w: recursive struct %s", ErrorExtract, t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	plan := &extractPlan{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(ExtractTag)
+		if !ok && strings.Contains(string(sf.Tag), ExtractTag+`:"`) {
+			// e.g. an invalid escape like `\;`, which makes the whole tag unreadable
+			return nil, fmt.Errorf("%w: field %s.%s: malformed tag %s", ErrorExtract, t.Name(), sf.Name, sf.Tag)
+		}
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		spec, err := parseFieldTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s.%s: %v", ErrorExtract, t.Name(), sf.Name, err)
+		}
+		spec.index, spec.name = i, sf.Name
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.Uint8 {
+			spec.all = true
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			if spec.sub, err = buildPlan(ft, visiting); err != nil {
+				return nil, err
+			}
+		}
+
+		plan.fields = append(plan.fields, spec)
+	}
+	return plan, nil
+}
+
+func parseFieldTag(tag string) (*fieldSpec, error) {
+	spec := &fieldSpec{}
+	for _, part := range splitEscaped(tag, ';') {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.TrimSpace(key) {
+		case "css":
+			spec.css = value
+		case "xpath":
+			spec.xpath = value
+		case "attr":
+			spec.attr = value
+		case "prop":
+			spec.prop = value
+		case "trim":
+			spec.trim = true
+		case "required":
+			spec.required = true
+		case "default":
+			v := value
+			spec.def = &v
+		case "regex":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, err
+			}
+			spec.regex = re
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return spec, nil
+}
+
+// splitEscaped splits s by sep, a doubled sep is kept as sep
+func splitEscaped(s string, sep byte) (parts []string) {
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == sep && i+1 < len(s) && s[i+1] == sep:
+			cur.WriteByte(sep)
+			i++
+		case s[i] == sep:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	return append(parts, cur.String())
+}
+
+// fill sets fields of struct v from raw values returned by extractJS
+func (p *extractPlan) fill(v reflect.Value, raw map[string]interface{}, path string, errs map[string]error) {
+	for _, f := range p.fields {
+		fv := v.Field(f.index)
+		fpath := strings.TrimPrefix(path+"."+f.name, ".")
+		val := raw[f.name]
+
+		if !f.all {
+			f.set(fv, val, fpath, errs)
+			continue
+		}
+
+		items, _ := val.([]interface{})
+		if len(items) == 0 && f.required {
+			errs[fpath] = ErrorSelNotFound
+		}
+		list := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			f.set(list.Index(i), item, fmt.Sprintf("%s[%d]", fpath, i), errs)
+		}
+		fv.Set(list)
+	}
+}
+
+// set sets a single value (scalar, struct or pointer) from raw
+func (f *fieldSpec) set(fv reflect.Value, raw interface{}, path string, errs map[string]error) {
+	if fv.Kind() == reflect.Ptr {
+		if raw == nil && f.def == nil {
+			if f.required {
+				errs[path] = ErrorSelNotFound
+			}
+			return
+		}
+		fv.Set(reflect.New(fv.Type().Elem()))
+		fv = fv.Elem()
+	}
+
+	if f.sub != nil {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			if f.required {
+				errs[path] = ErrorSelNotFound
+			}
+			return
+		}
+		f.sub.fill(fv, m, path, errs)
+		return
+	}
+
+	s, ok := f.value(raw)
+	if !ok {
+		if f.required {
+			errs[path] = ErrorSelNotFound
+		}
+		return
+	}
+
+	if err := setScalar(fv, s); err != nil {
+		errs[path] = err
+	}
+}
+
+// value applies trim/regex/default to raw, returns false if no value available
+func (f *fieldSpec) value(raw interface{}) (string, bool) {
+	s, ok := raw.(string)
+	if ok && f.trim {
+		s = strings.TrimSpace(s)
+	}
+
+	if ok && f.regex != nil {
+		m := f.regex.FindStringSubmatch(s)
+		switch {
+		case m == nil:
+			ok = false
+		case len(m) > 1:
+			s = m[1]
+		default:
+			s = m[0]
+		}
+	}
+
+	if !ok && f.def != nil {
+		return *f.def, true
+	}
+	return s, ok
+}
+
+func setScalar(fv reflect.Value, s string) error {
+	if fv.Type() == timeType {
+		t, err := cast.ToTimeE(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		v, err := cast.ToBoolE(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// base 10 always, leading zeros like "010" are not octal
+		v, err := strconv.ParseInt(strings.TrimSpace(s), 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(strings.TrimSpace(s), 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := cast.ToFloat64E(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(v)
+	case reflect.Slice:
+		// []byte
+		fv.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
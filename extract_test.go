@@ -0,0 +1,188 @@
+package xbot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ExtractSuite struct {
+	suite.Suite
+}
+
+func TestExtract(t *testing.T) {
+	suite.Run(t, new(ExtractSuite))
+}
+
+type extractItem struct {
+	Title string   `xbot:"css=h2;trim"`
+	Price float64  `xbot:"css=.price;attr=data-value;regex=([\\d.]+)"`
+	Stock int      `xbot:"css=.stock;default=0"`
+	Link  *string  `xbot:"css=a;prop=href"`
+	Tags  []string `xbot:"css=.tag;trim"`
+	SKU   string   `xbot:"css=.sku;required"`
+	skip  string   `xbot:"css=.skip"`
+	Note  string
+}
+
+type extractPage struct {
+	Heading string        `xbot:"css=h1"`
+	Items   []extractItem `xbot:"css=li.item"`
+	Total   int           `xbot:"css=.total;regex=\\d+"`
+}
+
+func (s *ExtractSuite) Test_01_ParseTag() {
+	spec, err := parseFieldTag(`css=a;;b;attr=href;trim;regex=x;;y;default=;required`)
+	s.Require().NoError(err)
+	s.Equal("a;b", spec.css)
+	s.Equal("href", spec.attr)
+	s.True(spec.trim)
+	s.True(spec.required)
+	s.Equal("x;y", spec.regex.String())
+	s.Equal("", *spec.def)
+
+	_, err = parseFieldTag("css=a;unknown=1")
+	s.Error(err)
+	_, err = parseFieldTag("regex=(")
+	s.Error(err)
+}
+
+func (s *ExtractSuite) Test_02_Plan() {
+	plan, err := planOf(reflect.TypeOf(extractPage{}))
+	s.Require().NoError(err)
+
+	js := plan.js()
+	s.Len(js, 3)
+	s.Equal("Items", js[1].Name)
+	s.True(js[1].All)
+	s.Len(js[1].Sub, 6, "unexported and untagged fields are skipped")
+	s.Equal("href", js[1].Sub[3].Prop)
+	s.True(js[1].Sub[4].All)
+
+	type loop struct {
+		Next *loop `xbot:"css=a"`
+	}
+	_, err = planOf(reflect.TypeOf(loop{}))
+	s.True(errors.Is(err, ErrorExtract))
+
+	// `\;` is an invalid escape of go string, the field must not be skipped silently,
+	// the type is built at runtime, since vet rejects such tag in source
+	malformed := reflect.StructOf([]reflect.StructField{
+		{Name: "A", Type: reflect.TypeOf(""), Tag: `xbot:"css=a\;b"`},
+	})
+	_, err = planOf(malformed)
+	s.True(errors.Is(err, ErrorExtract))
+	s.ErrorContains(err, "malformed tag")
+}
+
+func (s *ExtractSuite) Test_03_Fill() {
+	plan, err := planOf(reflect.TypeOf(extractPage{}))
+	s.Require().NoError(err)
+
+	raw := map[string]interface{}{
+		"Heading": "Shop",
+		"Total":   "Total: 2 items",
+		"Items": []interface{}{
+			map[string]interface{}{
+				"Title": "  Apple ", "Price": "$1.25", "Stock": "7", "Link": "https://x/a",
+				"Tags": []interface{}{" red ", "fruit"}, "SKU": "A1",
+			},
+			map[string]interface{}{
+				"Title": "Pear", "Price": "n/a", "Stock": nil, "Link": nil,
+				"Tags": []interface{}{}, "SKU": nil,
+			},
+		},
+	}
+
+	var got extractPage
+	errs := map[string]error{}
+	plan.fill(reflect.ValueOf(&got).Elem(), raw, "", errs)
+
+	s.Equal("Shop", got.Heading)
+	s.Equal(2, got.Total)
+	s.Len(got.Items, 2)
+
+	a := got.Items[0]
+	s.Equal("Apple", a.Title)
+	s.Equal(1.25, a.Price)
+	s.Equal(7, a.Stock)
+	s.Equal("https://x/a", *a.Link)
+	s.Equal([]string{"red", "fruit"}, a.Tags)
+
+	p := got.Items[1]
+	s.Equal(0.0, p.Price, "regex not matched without default")
+	s.Equal(0, p.Stock)
+	s.Nil(p.Link)
+	s.Empty(p.Tags)
+
+	s.Len(errs, 1)
+	s.ErrorIs(errs["Items[1].SKU"], ErrorSelNotFound)
+
+	err = &ExtractError{Fields: errs}
+	s.True(errors.Is(err, ErrorExtract))
+	s.Contains(err.Error(), "Items[1].SKU")
+}
+
+func (s *ExtractSuite) Test_04_Convert() {
+	type conv struct {
+		N int  `xbot:"css=.n"`
+		B bool `xbot:"css=.b"`
+	}
+	plan, err := planOf(reflect.TypeOf(conv{}))
+	s.Require().NoError(err)
+
+	var got conv
+	errs := map[string]error{}
+	plan.fill(reflect.ValueOf(&got).Elem(), map[string]interface{}{"N": "abc", "B": "true"}, "", errs)
+	s.True(got.B)
+	s.Contains(errs, "N")
+
+	// numbers are always base 10
+	type nums struct {
+		I  int    `xbot:"css=.i"`
+		U  uint16 `xbot:"css=.u"`
+		I8 int8   `xbot:"css=.i8"`
+	}
+	numPlan, err := planOf(reflect.TypeOf(nums{}))
+	s.Require().NoError(err)
+	var n nums
+	errs = map[string]error{}
+	numPlan.fill(reflect.ValueOf(&n).Elem(), map[string]interface{}{"I": "010", "U": " 08 ", "I8": "300"}, "", errs)
+	s.Equal(10, n.I)
+	s.Equal(uint16(8), n.U)
+	s.Contains(errs, "I8", "overflow is reported")
+
+	b := &Bot{}
+	s.True(errors.Is(b.Extract(nil, got), ErrorExtract))
+	s.True(errors.Is(b.Extract(nil, &[]int{}), ErrorExtract))
+	s.True(errors.Is(b.Extract(nil, &[]conv{}), ErrorExtract))
+}
+
+// Test_05_SlowList extracts a list which is rendered after the page loaded
+func (s *ExtractSuite) Test_05_SlowList() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><ul></ul><script>
+			setTimeout(() => {
+				document.querySelector("ul").innerHTML = "<li class=item><h2>a</h2></li><li class=item><h2>b</h2></li>"
+			}, 1000)
+		</script></body></html>`))
+	}))
+	defer srv.Close()
+
+	b, err := NewBotE(BotUserAgent(UA), BotHeadless(true))
+	if err != nil {
+		s.T().Skipf("browser not available: %v", err)
+	}
+	defer b.Close()
+	s.Require().NoError(b.GetPageE(srv.URL))
+
+	var items []struct {
+		Title string `xbot:"css=h2"`
+	}
+	s.Require().NoError(b.Extract("li.item", &items))
+	s.Len(items, 2)
+}
@@ -0,0 +1,172 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+var ErrorFrameNotFound = errors.New("iframe not found")
+
+// frameSpec locates an iframe element within its parent page, by selector or by url
+type frameSpec struct {
+	sel interface{}
+	url string
+}
+
+func (f frameSpec) String() string {
+	if f.url != "" {
+		return "url=" + f.url
+	}
+	return fmt.Sprintf("%v", f.sel)
+}
+
+// findFrameByURLJS finds the iframe whose url contains s,
+// cross-origin iframes are matched by their src attribute
+const findFrameByURLJS = `function(s) {
+	return Array.from(document.querySelectorAll("iframe, frame")).find((f) => {
+		let href = f.src
+		try {
+			href = f.contentWindow.location.href
+		} catch (e) {}
+		return (href || "").includes(s)
+	}) || null
+}`
+
+// elemBoxJS returns the box of element relative to the top page,
+// offsets of the (same-origin) iframes it lives in are added
+const elemBoxJS = `function() {
+	const r = this.getBoundingClientRect()
+	let x = 0, y = 0
+	try {
+		for (let w = this.ownerDocument.defaultView; w.frameElement; w = w.parent) {
+			const f = w.frameElement, fr = f.getBoundingClientRect()
+			x += fr.left + f.clientLeft
+			y += fr.top + f.clientTop
+		}
+	} catch (e) {}
+	return JSON.stringify({
+		x: r.x + x, y: r.y + y, width: r.width, height: r.height,
+		top: r.top + y, right: r.right + x, bottom: r.bottom + y, left: r.left + x,
+	})
+}`
+
+// Frame resolves the iframe set by WithFrame/WithFrameName/WithFrameURL and waits for it to load,
+// returns b.Iframe if no frame is set in opts
+func (b *Bot) Frame(opts ...BotOptFunc) (*rod.Page, error) {
+	opt := BotOpts{Timeout: toSec(b.mediumToSec)}
+	BindBotOpts(&opt, opts...)
+
+	if len(opt.frames) == 0 {
+		return b.Iframe, nil
+	}
+	return b.resolveFrame(opt.frames, time.Duration(opt.Timeout)*time.Second)
+}
+
+func (b *Bot) MustFrame(opts ...BotOptFunc) *rod.Page {
+	frame, err := b.Frame(opts...)
+	b.PanicIfErr(err)
+	return frame
+}
+
+// BindFrame resolves the iframe by opts and binds it to b.Iframe,
+// so all lookups run inside the iframe until ResetIframe is called
+func (b *Bot) BindFrame(opts ...BotOptFunc) error {
+	frame, err := b.Frame(opts...)
+	if err != nil {
+		return err
+	}
+	b.BindIframe(frame)
+	return nil
+}
+
+func (b *Bot) ResetIframe() {
+	b.Iframe = nil
+}
+
+// resolveFrame resolves frames one by one, each frame is searched within the previous one,
+// all frames share the deadline of dur
+func (b *Bot) resolveFrame(frames []frameSpec, dur time.Duration) (*rod.Page, error) {
+	deadline := time.Now().Add(dur)
+
+	parent := b.Pg
+	for _, spec := range frames {
+		elem, err := b.waitFrameElem(parent, spec, remaining(deadline))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrorFrameNotFound, spec, err)
+		}
+
+		frame, err := elem.Frame()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrorFrameNotFound, spec, err)
+		}
+		// elem is found with the timeout, so is the frame cloned from it
+		frame = frame.Context(b.Pg.GetContext())
+
+		if err := frame.Timeout(remaining(deadline)).WaitLoad(); err != nil {
+			return nil, err
+		}
+		parent = frame
+	}
+
+	return parent, nil
+}
+
+// remaining returns the time left to deadline, at least a short while to check once more
+func remaining(deadline time.Time) time.Duration {
+	if d := time.Until(deadline); d > minRemaining {
+		return d
+	}
+	return minRemaining
+}
+
+// minRemaining is the least time given to a step when the shared deadline is (nearly) reached
+const minRemaining = 100 * time.Millisecond
+
+func (b *Bot) waitFrameElem(parent *rod.Page, spec frameSpec, dur time.Duration) (*rod.Element, error) {
+	if spec.url != "" {
+		return parent.Timeout(dur).ElementByJS(rod.Eval(findFrameByURLJS, spec.url))
+	}
+
+	sel, err := toSelector(spec.sel)
+	if err != nil {
+		return nil, err
+	}
+	sel.frame = parent
+	return b.waitElem(sel, dur)
+}
+
+// bindFrame sets the page which sel is searched in, from frames of opt or b.Iframe,
+// frames are resolved within opt.Timeout, the caller should wait elements for the time left only
+func (b *Bot) bindFrame(sel Selector, opt *BotOpts) (Selector, error) {
+	if sel.frame != nil {
+		return sel, nil
+	}
+
+	if len(opt.frames) == 0 {
+		sel.frame = b.Iframe
+		return sel, nil
+	}
+
+	dur := time.Duration(opt.Timeout) * time.Second
+	if dur == 0 {
+		dur = b.shortToSec
+	}
+
+	frame, err := b.resolveFrame(opt.frames, dur)
+	if err != nil {
+		return sel, err
+	}
+	sel.frame = frame
+	return sel, nil
+}
+
+// pageOf returns the page or iframe which sel is searched in
+func (b *Bot) pageOf(sel Selector) *rod.Page {
+	if sel.frame != nil {
+		return sel.frame
+	}
+	return b.Pg
+}
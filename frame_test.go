@@ -0,0 +1,88 @@
+package xbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FrameSuite struct {
+	suite.Suite
+	srv *httptest.Server
+	bot *Bot
+}
+
+func TestFrame(t *testing.T) {
+	suite.Run(t, new(FrameSuite))
+}
+
+func (s *FrameSuite) SetupSuite() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><p class=top>top</p><iframe name=inner src="/inner"></iframe></body></html>`))
+	})
+	mux.HandleFunc("/inner", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body>
+			<p class=in>inner</p>
+			<button class=popover onclick="this.remove()">close</button>
+		</body></html>`))
+	})
+	s.srv = httptest.NewServer(mux)
+
+	bot, err := NewBotE(BotUserAgent(UA), BotHeadless(true))
+	if err != nil {
+		s.T().Skipf("browser not available: %v", err)
+	}
+	s.bot = bot
+}
+
+func (s *FrameSuite) TearDownSuite() {
+	if s.bot != nil {
+		s.bot.Close()
+	}
+	s.srv.Close()
+}
+
+func (s *FrameSuite) SetupTest() {
+	s.bot.ResetIframe()
+	s.Require().NoError(s.bot.GetPageE(s.srv.URL))
+}
+
+func (s *FrameSuite) Test_01_GetElem() {
+	b := s.bot
+	s.Nil(b.GetElem("p.in", BotTimeout(0)), "not in top page")
+
+	elem := b.GetElem("p.in", WithFrameName("inner"), BotTimeout(3))
+	s.Require().NotNil(elem)
+	s.Equal("inner", elem.MustText())
+
+	s.Len(b.GetElems("p.in", WithFrame("iframe")), 1)
+}
+
+func (s *FrameSuite) Test_02_BoundFrame() {
+	b := s.bot
+	s.Require().NoError(b.BindFrame(WithFrameName("inner")))
+
+	sel, err := b.EnsureAnyElem("p.top", "p.in")
+	s.NoError(err)
+	s.Equal("p.in", sel, "lookups run inside the bound frame")
+
+	s.Equal(1, b.ClosePopover("button.popover"))
+	s.Empty(b.GetElems("button.popover"))
+}
+
+func (s *FrameSuite) Test_03_SharedDeadline() {
+	b := s.bot
+
+	ts := time.Now()
+	s.Nil(b.GetElem("p.in", WithFrame("iframe.missing"), BotTimeout(1)))
+	s.Less(time.Since(ts), 2*time.Second)
+
+	ts = time.Now()
+	_, _, err := b.WaitAnyElem([]interface{}{"p.missing"}, WithFrameName("inner"), BotTimeout(1))
+	s.Error(err)
+	s.Less(time.Since(ts), 2*time.Second, "frame resolution and the wait share the timeout")
+}
@@ -3,6 +3,7 @@ module github.com/coghost/xbot
 go 1.19
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/avast/retry-go v3.0.0+incompatible
 	github.com/coghost/xlog v0.0.0-20221026034900-066c4ea5110e
 	github.com/coghost/xpretty v0.0.0-20221010043412-c2eabe3e48d9
@@ -15,6 +16,9 @@ require (
 	github.com/spf13/cast v1.5.1
 	github.com/stretchr/testify v1.8.4
 	github.com/thoas/go-funk v0.9.3
+	github.com/ysmood/gson v0.7.3
+	gopkg.in/ini.v1 v1.67.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -55,7 +59,6 @@ require (
 	github.com/ysmood/fetchup v0.2.3 // indirect
 	github.com/ysmood/goob v0.4.0 // indirect
 	github.com/ysmood/got v0.34.1 // indirect
-	github.com/ysmood/gson v0.7.3 // indirect
 	github.com/ysmood/leakless v0.8.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.2 // indirect
 	golang.org/x/crypto v0.9.0 // indirect
@@ -66,5 +69,4 @@ require (
 	golang.org/x/text v0.11.0 // indirect
 	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,549 @@
+package xbot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// HARVersion is the version of HAR written by HARRecorder
+const HARVersion = "1.2"
+
+// DefaultHAREntries is the number of entries kept for each page by HARRecorder
+var DefaultHAREntries = 10000
+
+// HAR is the HTTP Archive 1.2, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Pages   []HARPage   `json:"pages"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HARPage struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     HARPageTimings `json:"pageTimings"`
+}
+
+type HARPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+type HAREntry struct {
+	Pageref         string      `json:"pageref,omitempty"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+
+	// WebSocketMessages is the custom field used by Chrome DevTools for websocket frames
+	WebSocketMessages []HARWebSocketMessage `json:"_webSocketMessages,omitempty"`
+	// ResourceType is the custom field used by Chrome DevTools
+	ResourceType string `json:"_resourceType,omitempty"`
+
+	started time.Time
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings are in milliseconds, -1 if not applicable
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+type HARWebSocketMessage struct {
+	Type string `json:"type"`
+	// Time is seconds since epoch
+	Time   float64 `json:"time"`
+	Opcode float64 `json:"opcode"`
+	Data   string  `json:"data"`
+}
+
+type HAROpts struct {
+	bodies     bool
+	maxEntries int
+}
+
+type HAROptFunc func(o *HAROpts)
+
+func BindHAROpts(opt *HAROpts, opts ...HAROptFunc) {
+	for _, f := range opts {
+		f(opt)
+	}
+}
+
+// WithHARBodies records response bodies, which are captured once loading finished
+func WithHARBodies(b bool) HAROptFunc {
+	return func(o *HAROpts) {
+		o.bodies = b
+	}
+}
+
+// WithHARMaxEntries sets the number of entries kept for each page, DefaultHAREntries by default
+func WithHARMaxEntries(n int) HAROptFunc {
+	return func(o *HAROpts) {
+		o.maxEntries = n
+	}
+}
+
+// harPage is a page recorded by HARRecorder
+type harPage struct {
+	id      string
+	page    *rod.Page
+	started time.Time
+	rec     *NetworkRecorder
+}
+
+// HARRecorder records pages into a HAR, it's built on NetworkRecorder
+type HARRecorder struct {
+	opt HAROpts
+
+	mu    sync.Mutex
+	pages []*harPage
+}
+
+func NewHARRecorder(opts ...HAROptFunc) *HARRecorder {
+	opt := HAROpts{maxEntries: DefaultHAREntries}
+	BindHAROpts(&opt, opts...)
+	return &HARRecorder{opt: opt}
+}
+
+// AddPage starts recording page, e.g. a popup opened by ClickAndSwitchToNewPage
+func (h *HARRecorder) AddPage(page *rod.Page) {
+	rec := NewNetworkRecorder(page, h.opt.maxEntries)
+	rec.CaptureBodies(h.opt.bodies)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pages = append(h.pages, &harPage{
+		id:      fmt.Sprintf("page_%d", len(h.pages)+1),
+		page:    page,
+		started: time.Now(),
+		rec:     rec,
+	})
+}
+
+// Stop stops recording all pages, the recorded can still be written
+func (h *HARRecorder) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, p := range h.pages {
+		p.rec.Stop()
+	}
+}
+
+// HAR builds the HAR of recorded pages, entries are sorted by start time,
+// unfinished requests without response are skipped
+func (h *HARRecorder) HAR() *HAR {
+	h.mu.Lock()
+	pages := append([]*harPage(nil), h.pages...)
+	h.mu.Unlock()
+
+	har := &HAR{Log: HARLog{
+		Version: HARVersion,
+		Creator: HARCreator{Name: "xbot", Version: moduleVersion()},
+		Pages:   []HARPage{},
+		Entries: []*HAREntry{},
+	}}
+
+	for _, p := range pages {
+		har.Log.Pages = append(har.Log.Pages, HARPage{
+			StartedDateTime: formatHARTime(p.started),
+			ID:              p.id,
+			Title:           pageTitle(p.page),
+			PageTimings:     HARPageTimings{OnContentLoad: -1, OnLoad: -1},
+		})
+
+		for _, e := range p.rec.Entries() {
+			if !e.Done() && e.Response == nil {
+				continue
+			}
+
+			var body []byte
+			if h.opt.bodies && !e.Redirected && e.Type != proto.NetworkResourceTypeWebSocket {
+				body, _ = e.Body()
+			}
+
+			ent := toHAREntry(e, body)
+			ent.Pageref = p.id
+			har.Log.Entries = append(har.Log.Entries, ent)
+		}
+	}
+
+	sort.SliceStable(har.Log.Entries, func(i, j int) bool {
+		return har.Log.Entries[i].started.Before(har.Log.Entries[j].started)
+	})
+	return har
+}
+
+// Write writes the HAR as json
+func (h *HARRecorder) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(h.HAR())
+}
+
+// WriteFile writes the HAR to path, e.g. "~/tmp/crawl.har"
+func (h *HARRecorder) WriteFile(path string) error {
+	path = expandPath(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := h.Write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// StartHAR starts recording current page into HAR, and stops the previous one of bot
+//
+//	b.StartHAR(WithHARBodies(true))
+//	defer b.StopHAR("/tmp/crawl.har")
+func (b *Bot) StartHAR(opts ...HAROptFunc) *HARRecorder {
+	if b.har != nil {
+		b.har.Stop()
+	}
+	b.har = NewHARRecorder(opts...)
+	b.har.AddPage(b.Pg)
+	return b.har
+}
+
+// HARRecorder returns the recorder started by StartHAR, nil if not started
+func (b *Bot) HARRecorder() *HARRecorder {
+	return b.har
+}
+
+// StopHAR stops recording and writes the HAR to path if it's not empty
+func (b *Bot) StopHAR(path string) error {
+	if b.har == nil {
+		return nil
+	}
+
+	h := b.har
+	b.har = nil
+	h.Stop()
+
+	if path == "" {
+		return nil
+	}
+	return h.WriteFile(path)
+}
+
+// moduleVersion returns the version of xbot module in build info, "devel" if unknown
+func moduleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/coghost/xbot" {
+				return dep.Version
+			}
+		}
+	}
+	return "devel"
+}
+
+func pageTitle(page *rod.Page) string {
+	if page == nil {
+		return ""
+	}
+	info, err := page.Timeout(time.Second).Info()
+	if err != nil {
+		return ""
+	}
+	return info.Title
+}
+
+func formatHARTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func toHAREntry(e *NetworkEntry, body []byte) *HAREntry {
+	req := e.Request
+	if req == nil {
+		req = &proto.NetworkRequest{}
+	}
+
+	ent := &HAREntry{
+		StartedDateTime: formatHARTime(e.WallTime),
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(req.Headers),
+			QueryString: harQuery(req.URL),
+			HeadersSize: -1,
+			BodySize:    len(req.PostData),
+		},
+		Response: HARResponse{
+			Cookies:     []HARNameValue{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Comment:      e.Failed,
+		ResourceType: strings.ToLower(string(e.Type)),
+		started:      e.WallTime,
+	}
+
+	if req.HasPostData || req.PostData != "" {
+		ent.Request.PostData = &HARPostData{MimeType: headerValue(req.Headers, "Content-Type"), Text: req.PostData}
+	}
+
+	if res := e.Response; res != nil {
+		ent.Request.HTTPVersion = harHTTPVersion(res.Protocol)
+		ent.Response = HARResponse{
+			Status:      res.Status,
+			StatusText:  res.StatusText,
+			HTTPVersion: harHTTPVersion(res.Protocol),
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(res.Headers),
+			Content:     harContent(res.MIMEType, body),
+			HeadersSize: -1,
+			BodySize:    int(e.EncodedDataLength),
+			RedirectURL: headerValue(res.Headers, "Location"),
+		}
+		if e.EncodedDataLength == 0 {
+			ent.Response.BodySize = -1
+		}
+		if res.RequestHeaders != nil {
+			ent.Request.Headers = harHeaders(res.RequestHeaders)
+		}
+		ent.ServerIPAddress = res.RemoteIPAddress
+	}
+
+	ent.Timings = harTimings(e)
+	ent.Time = harTotal(ent.Timings)
+
+	for _, f := range e.Frames {
+		typ := "receive"
+		if f.Sent {
+			typ = "send"
+		}
+		at := e.WallTime.Add((f.Timestamp - e.Started).Duration())
+		ent.WebSocketMessages = append(ent.WebSocketMessages, HARWebSocketMessage{
+			Type:   typ,
+			Time:   float64(at.UnixNano()) / float64(time.Second),
+			Opcode: f.Opcode,
+			Data:   f.Data,
+		})
+	}
+
+	return ent
+}
+
+// harTimings converts the timing of response (ms relative to requestTime) to HAR timings
+func harTimings(e *NetworkEntry) HARTimings {
+	ms := func(t proto.MonotonicTime) float64 { return float64(t) * 1000 }
+	t := HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+
+	var timing *proto.NetworkResourceTiming
+	if e.Response != nil {
+		timing = e.Response.Timing
+	}
+
+	if timing == nil {
+		if e.Responded != 0 {
+			t.Wait = nonNegative(ms(e.Responded) - ms(e.Started))
+			t.Receive = nonNegative(ms(e.Finished) - ms(e.Responded))
+		} else if e.Finished != 0 {
+			t.Wait = nonNegative(ms(e.Finished) - ms(e.Started))
+		}
+		return t
+	}
+
+	started := timing.RequestTime * 1000
+	t.Blocked = nonNegative(started - ms(e.Started))
+
+	first := timing.SendStart
+	for _, v := range []float64{timing.DNSStart, timing.ConnectStart} {
+		if v >= 0 && v < first {
+			first = v
+		}
+	}
+	t.Blocked += nonNegative(first)
+
+	if timing.DNSStart >= 0 {
+		t.DNS = timing.DNSEnd - timing.DNSStart
+	}
+	if timing.ConnectStart >= 0 {
+		t.Connect = timing.ConnectEnd - timing.ConnectStart
+	}
+	if timing.SslStart >= 0 {
+		t.SSL = timing.SslEnd - timing.SslStart
+	}
+	t.Send = nonNegative(timing.SendEnd - timing.SendStart)
+	t.Wait = nonNegative(timing.ReceiveHeadersEnd - timing.SendEnd)
+	if e.Finished != 0 {
+		t.Receive = nonNegative(ms(e.Finished) - started - timing.ReceiveHeadersEnd)
+	}
+	return t
+}
+
+// harTotal sums timings except ssl, which is included in connect
+func harTotal(t HARTimings) float64 {
+	total := 0.0
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		total += nonNegative(v)
+	}
+	return math.Round(total*1000) / 1000
+}
+
+func nonNegative(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "":
+		return "HTTP/1.1"
+	case "h2":
+		return "HTTP/2.0"
+	case "h3", "h3-29", "quic":
+		return "HTTP/3.0"
+	default:
+		return strings.ToUpper(protocol)
+	}
+}
+
+// harHeaders converts headers to name/value pairs sorted by name, multi values joined by "\n" are split
+func harHeaders(headers proto.NetworkHeaders) []HARNameValue {
+	out := []HARNameValue{}
+	for name, v := range headers {
+		for _, val := range strings.Split(v.Str(), "\n") {
+			out = append(out, HARNameValue{Name: name, Value: val})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func headerValue(headers proto.NetworkHeaders, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v.Str()
+		}
+	}
+	return ""
+}
+
+func harQuery(raw string) []HARNameValue {
+	out := []HARNameValue{}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return out
+	}
+
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range q[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+// harContent keeps text body as is, and encodes binary body with base64
+func harContent(mimeType string, body []byte) HARContent {
+	c := HARContent{Size: len(body), MimeType: mimeType}
+	if body == nil {
+		return c
+	}
+
+	if utf8.Valid(body) {
+		c.Text = string(body)
+	} else {
+		c.Text, c.Encoding = base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	return c
+}
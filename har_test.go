@@ -0,0 +1,121 @@
+package xbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/suite"
+	"github.com/ysmood/gson"
+)
+
+type HARSuite struct {
+	suite.Suite
+}
+
+func TestHAR(t *testing.T) {
+	suite.Run(t, new(HARSuite))
+}
+
+func (s *HARSuite) Test_01_Timings() {
+	e := &NetworkEntry{
+		Started: 10,
+		Response: &proto.NetworkResponse{Timing: &proto.NetworkResourceTiming{
+			RequestTime:       10.001,
+			DNSStart:          1,
+			DNSEnd:            3,
+			ConnectStart:      3,
+			ConnectEnd:        10,
+			SslStart:          5,
+			SslEnd:            10,
+			SendStart:         10,
+			SendEnd:           11,
+			ReceiveHeadersEnd: 51,
+		}},
+		Finished: 10.071,
+	}
+
+	t := harTimings(e)
+	s.InDelta(2, t.Blocked, 1e-6)
+	s.InDelta(2, t.DNS, 1e-6)
+	s.InDelta(7, t.Connect, 1e-6)
+	s.InDelta(5, t.SSL, 1e-6)
+	s.InDelta(1, t.Send, 1e-6)
+	s.InDelta(40, t.Wait, 1e-6)
+	s.InDelta(19, t.Receive, 1e-6)
+	s.InDelta(71, harTotal(t), 1e-6)
+
+	// cached or failed requests have no timing
+	t = harTimings(&NetworkEntry{Started: 1, Responded: 1.2, Finished: 1.5})
+	s.Equal(-1.0, t.DNS)
+	s.InDelta(200, t.Wait, 1e-6)
+	s.InDelta(300, t.Receive, 1e-6)
+}
+
+func (s *HARSuite) Test_02_Entry() {
+	e := &NetworkEntry{
+		Type: proto.NetworkResourceTypeFetch,
+		Request: &proto.NetworkRequest{
+			URL:      "https://a.com/api?b=2&a=1&a=0",
+			Method:   "POST",
+			Headers:  proto.NetworkHeaders{"Content-Type": gson.New("application/json")},
+			PostData: `{"q":1}`,
+		},
+		Response: &proto.NetworkResponse{
+			Status:   302,
+			Protocol: "h2",
+			MIMEType: "text/html",
+			Headers:  proto.NetworkHeaders{"location": gson.New("/next"), "set-cookie": gson.New("a=1\nb=2")},
+		},
+		WallTime: time.Date(2022, 1, 2, 3, 4, 5, 6e6, time.UTC),
+	}
+
+	ent := toHAREntry(e, []byte{0xff, 0x00})
+	s.Equal("2022-01-02T03:04:05.006Z", ent.StartedDateTime)
+	s.Equal("HTTP/2.0", ent.Response.HTTPVersion)
+	s.Equal("/next", ent.Response.RedirectURL)
+	s.Equal([]HARNameValue{{"a", "1"}, {"a", "0"}, {"b", "2"}}, ent.Request.QueryString)
+	s.Equal(&HARPostData{MimeType: "application/json", Text: `{"q":1}`}, ent.Request.PostData)
+	s.Equal([]HARNameValue{{"location", "/next"}, {"set-cookie", "a=1"}, {"set-cookie", "b=2"}}, ent.Response.Headers)
+	s.Equal(HARContent{Size: 2, MimeType: "text/html", Text: "/wA=", Encoding: "base64"}, ent.Response.Content)
+	s.Equal("fetch", ent.ResourceType)
+}
+
+func (s *HARSuite) Test_03_Write() {
+	r := newNetworkRecorder(nil, 0)
+	sendRequest(r, "2", "https://a.com/later", 2)
+	respond(r, "2", 200, 3)
+	sendRequest(r, "3", "https://a.com/pending", 4)
+	r.update(func() {
+		r.byID["2"].WallTime = time.Unix(100, 0)
+	})
+	sendRequest(r, "1", "https://a.com/earlier", 1)
+	r.onFailed(&proto.NetworkLoadingFailed{RequestID: "1", ErrorText: "net::ERR_ABORTED", Timestamp: 2})
+	r.update(func() {
+		r.byID["1"].WallTime = time.Unix(50, 0)
+	})
+
+	h := NewHARRecorder()
+	h.pages = append(h.pages, &harPage{id: "page_1", rec: r, started: time.Unix(0, 0)})
+
+	har := h.HAR()
+	s.Equal(HARVersion, har.Log.Version)
+	s.Require().Len(har.Log.Pages, 1)
+	s.Require().Len(har.Log.Entries, 2)
+	s.Equal("https://a.com/earlier", har.Log.Entries[0].Request.URL)
+	s.Equal("net::ERR_ABORTED", har.Log.Entries[0].Comment)
+	s.Equal("page_1", har.Log.Entries[1].Pageref)
+
+	var buf bytes.Buffer
+	s.Require().NoError(h.Write(&buf))
+	var raw map[string]map[string]interface{}
+	s.Require().NoError(json.Unmarshal(buf.Bytes(), &raw))
+	s.Len(raw["log"]["entries"], 2)
+
+	path := filepath.Join(s.T().TempDir(), "a", "crawl.har")
+	s.NoError(h.WriteFile(path))
+	s.FileExists(path)
+}
@@ -0,0 +1,328 @@
+package xbot
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rs/zerolog/log"
+)
+
+// HarvestStop is the reason why a harvest stopped
+type HarvestStop string
+
+const (
+	HarvestPlateau   HarvestStop = "item count plateaued"
+	HarvestLimit     HarvestStop = "item limit reached"
+	HarvestMaxRounds HarvestStop = "max rounds reached"
+)
+
+// scrollContainerJS returns the nearest scrollable ancestor of this (itself included),
+// the document of an iframe, or null when the page itself scrolls
+const scrollContainerJS = `function() {
+	for (let el = this; el && el !== document.body && el !== document.documentElement; el = el.parentElement) {
+		const overflow = getComputedStyle(el).overflowY
+		if (/(auto|scroll|overlay)/.test(overflow) && el.scrollHeight > el.clientHeight) return el
+	}
+	return window.self !== window.top ? document.scrollingElement || document.documentElement : null
+}`
+
+// scrollRemainJS returns the distance of this container to its bottom, at least its visible height,
+// the page is measured when this is not an element
+const scrollRemainJS = `function() {
+	if (this instanceof Element) {
+		return Math.max(this.scrollHeight - this.scrollTop - this.clientHeight, this.clientHeight)
+	}
+	const el = document.scrollingElement || document.documentElement
+	return Math.max(el.scrollHeight - window.scrollY - window.innerHeight, window.innerHeight)
+}`
+
+type HarvestOpts struct {
+	keys    []string
+	itemKey func(item map[string]string) string
+
+	plateau   int
+	limit     int
+	maxRounds int
+	// wait is how long to wait for new items after each scroll
+	wait time.Duration
+
+	onItems func(fresh []map[string]string) error
+	botOpts []BotOptFunc
+}
+
+type HarvestOptFunc func(o *HarvestOpts)
+
+func BindHarvestOpts(opt *HarvestOpts, opts ...HarvestOptFunc) {
+	for _, f := range opts {
+		f(opt)
+	}
+}
+
+// WithHarvestKeys sets keys read from each item like GetElemsAttrs, ["innerText"] by default
+func WithHarvestKeys(keys ...string) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.keys = keys
+	}
+}
+
+// WithHarvestItemKey sets the key to de-duplicate items, all values of item by default
+func WithHarvestItemKey(fn func(item map[string]string) string) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.itemKey = fn
+	}
+}
+
+// WithPlateau stops after n scroll rounds without new items (3 by default)
+func WithPlateau(n int) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.plateau = n
+	}
+}
+
+// WithItemLimit stops once n items are collected, 0 means no limit
+func WithItemLimit(n int) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.limit = n
+	}
+}
+
+// WithMaxRounds stops after n scroll rounds, 0 means no limit
+func WithMaxRounds(n int) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.maxRounds = n
+	}
+}
+
+// WithRoundWait sets how long to wait for new items after each scroll, short timeout by default
+func WithRoundWait(d time.Duration) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.wait = d
+	}
+}
+
+// WithHarvestFunc calls fn with new items of each round, harvest stops if fn returns error
+func WithHarvestFunc(fn func(fresh []map[string]string) error) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.onItems = fn
+	}
+}
+
+// WithHarvestBotOpts passes opts to GetElemsAttrs and ScrollLikeHuman, e.g. WithRoot/WithFrame/WithSteps
+func WithHarvestBotOpts(opts ...BotOptFunc) HarvestOptFunc {
+	return func(o *HarvestOpts) {
+		o.botOpts = opts
+	}
+}
+
+// HarvestResult is returned by HarvestScroll
+type HarvestResult struct {
+	Items []map[string]string
+	// Rounds is the number of scroll rounds
+	Rounds int
+	Stop   HarvestStop
+}
+
+// harvester collects items incrementally
+type harvester struct {
+	opt   HarvestOpts
+	seen  map[string]struct{}
+	items []map[string]string
+}
+
+func newHarvester(opt HarvestOpts) *harvester {
+	return &harvester{opt: opt, seen: make(map[string]struct{})}
+}
+
+// add collects items not seen before, and returns them
+func (h *harvester) add(rows []map[string]string) (fresh []map[string]string) {
+	for _, row := range rows {
+		if h.full() {
+			break
+		}
+
+		key := h.opt.itemKey(row)
+		if _, ok := h.seen[key]; ok {
+			continue
+		}
+		h.seen[key] = struct{}{}
+		fresh = append(fresh, row)
+		h.items = append(h.items, row)
+	}
+	return fresh
+}
+
+func (h *harvester) full() bool {
+	return h.opt.limit > 0 && len(h.items) >= h.opt.limit
+}
+
+// defaultHarvestKey joins values in the order of keys
+func defaultHarvestKey(keys []string) func(item map[string]string) string {
+	return func(item map[string]string) string {
+		vals := make([]string, 0, len(keys))
+		for _, k := range keys {
+			vals = append(vals, item[k])
+		}
+		return strings.Join(vals, "\x00")
+	}
+}
+
+// HarvestScroll collects items of selector on an infinite-scroll page:
+// it scrolls like human round by round, waits for new items, and de-duplicates them by key,
+// until no new items for WithPlateau rounds, WithItemLimit or WithMaxRounds is hit.
+//
+// selector is a string, Selector, SelectorChain or element name of registry, Nth of Selector is ignored,
+// and its Root is used as WithRoot.
+// The nearest scrollable ancestor of WithRoot or the items is scrolled, so feeds in an overflow container work too.
+//
+//	res, err := b.HarvestScroll("div.feed article", WithHarvestKeys("data-id", "innerText"), WithItemLimit(200))
+func (b *Bot) HarvestScroll(selector interface{}, opts ...HarvestOptFunc) (*HarvestResult, error) {
+	opt := HarvestOpts{keys: []string{"innerText"}, plateau: 3, wait: b.shortToSec}
+	BindHarvestOpts(&opt, opts...)
+	if opt.itemKey == nil {
+		opt.itemKey = defaultHarvestKey(opt.keys)
+	}
+	// Root of Selector works as WithRoot, for both reading items and finding the container to scroll
+	if sel, err := toSelector(selector); err == nil && sel.Root != nil {
+		opt.botOpts = append(append([]BotOptFunc{}, opt.botOpts...), WithRoot(sel.Root))
+	}
+
+	h := newHarvester(opt)
+	res := &HarvestResult{}
+
+	// items already on page
+	if _, err := h.collect(b, selector, 0); err != nil {
+		return res, err
+	}
+
+	idle := 0
+	for {
+		switch {
+		case h.full():
+			res.Stop = HarvestLimit
+		case opt.maxRounds > 0 && res.Rounds >= opt.maxRounds:
+			res.Stop = HarvestMaxRounds
+		case idle >= opt.plateau:
+			res.Stop = HarvestPlateau
+		}
+		if res.Stop != "" {
+			break
+		}
+
+		if err := b.scrollOneRound(b.scrollContainer(selector, opt.botOpts...), opt.botOpts...); err != nil {
+			res.Items = h.items
+			return res, err
+		}
+		res.Rounds++
+
+		n, err := h.collect(b, selector, opt.wait)
+		if err != nil {
+			res.Items = h.items
+			return res, err
+		}
+		if n == 0 {
+			idle++
+		} else {
+			idle = 0
+		}
+		log.Debug().Int("round", res.Rounds).Int("new", n).Int("total", len(h.items)).Msg("harvest scroll")
+	}
+
+	res.Items = h.items
+	return res, nil
+}
+
+// scrollContainer returns the nearest scrollable ancestor of WithRoot or the first item, nil means the page
+func (b *Bot) scrollContainer(selector interface{}, opts ...BotOptFunc) *rod.Element {
+	opt := BotOpts{root: b.root}
+	BindBotOpts(&opt, opts...)
+
+	from := opt.root
+	if from == nil {
+		first := append([]BotOptFunc{}, opts...)
+		from = b.GetElem(selector, append(first, BotTimeout(0), ElemIndex(0))...)
+	}
+	if from == nil {
+		return nil
+	}
+
+	obj, err := from.Timeout(b.shortToSec).Evaluate(rod.Eval(scrollContainerJS).ByObject())
+	if err != nil || obj.ObjectID == "" {
+		return nil
+	}
+
+	container, err := from.Page().ElementFromObject(obj)
+	if err != nil {
+		return nil
+	}
+	return container
+}
+
+// scrollOneRound scrolls like human to the bottom of container's current content,
+// the mouse is moved into container first, since the wheel scrolls what is under it
+func (b *Bot) scrollOneRound(container *rod.Element, opts ...BotOptFunc) error {
+	if container == nil {
+		obj, err := b.Pg.Timeout(b.shortToSec).Eval(scrollRemainJS)
+		if err != nil {
+			return err
+		}
+		return b.ScrollLikeHuman(0, obj.Value.Num(), opts...)
+	}
+
+	obj, err := container.Timeout(b.shortToSec).Eval(scrollRemainJS)
+	if err != nil {
+		return err
+	}
+	if err := b.moveMouseInto(container); err != nil {
+		return err
+	}
+	return b.ScrollLikeHuman(0, obj.Value.Num(), opts...)
+}
+
+// moveMouseInto moves the mouse to a random point of the visible part of elem
+func (b *Bot) moveMouseInto(elem *rod.Element) error {
+	box, err := b.GetElemBox(elem)
+	if err != nil {
+		return err
+	}
+
+	top, bottom := math.Max(box.Top, 0), math.Min(box.Bottom, b.GetWindowInnerHeight())
+	if bottom <= top {
+		bottom = top + 1
+	}
+	pt := proto.Point{
+		X: box.Left + box.Width*(0.3+0.4*rand.Float64()),
+		Y: top + (bottom-top)*(0.3+0.4*rand.Float64()),
+	}
+	return b.Pg.Mouse.MoveLinear(pt, 8)
+}
+
+// collect polls items of selector until new ones appear or wait elapsed, returns the number of new items
+func (h *harvester) collect(b *Bot, selector interface{}, wait time.Duration) (int, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		rows, err := b.getElemsAttrs([]interface{}{selector}, h.opt.keys, h.opt.botOpts...)
+		if err != nil {
+			return 0, err
+		}
+
+		if fresh := h.add(rows); len(fresh) != 0 {
+			if h.opt.onItems != nil {
+				if err := h.opt.onItems(fresh); err != nil {
+					return len(fresh), err
+				}
+			}
+			return len(fresh), nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return 0, nil
+		}
+		if err := b.randSleep(0.2, 0.3); err != nil {
+			return 0, err
+		}
+	}
+}
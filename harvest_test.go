@@ -0,0 +1,75 @@
+package xbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HarvestSuite struct {
+	suite.Suite
+}
+
+func TestHarvest(t *testing.T) {
+	suite.Run(t, new(HarvestSuite))
+}
+
+func (s *HarvestSuite) Test_01_Dedup() {
+	keys := []string{"data-id", "innerText"}
+	h := newHarvester(HarvestOpts{keys: keys, itemKey: defaultHarvestKey(keys)})
+
+	fresh := h.add([]map[string]string{
+		{"data-id": "1", "innerText": "a"},
+		{"data-id": "2", "innerText": "b"},
+		{"data-id": "1", "innerText": "a"},
+	})
+	s.Len(fresh, 2)
+
+	// virtualized lists drop items scrolled away
+	fresh = h.add([]map[string]string{
+		{"data-id": "2", "innerText": "b"},
+		{"data-id": "3", "innerText": "c"},
+	})
+	s.Equal([]map[string]string{{"data-id": "3", "innerText": "c"}}, fresh)
+	s.Len(h.items, 3)
+
+	s.NotEqual(defaultHarvestKey(keys)(map[string]string{"data-id": "1a"}),
+		defaultHarvestKey(keys)(map[string]string{"data-id": "1", "innerText": "a"}))
+}
+
+func (s *HarvestSuite) Test_02_Limit() {
+	h := newHarvester(HarvestOpts{
+		limit:   2,
+		itemKey: func(item map[string]string) string { return item["id"] },
+	})
+
+	fresh := h.add([]map[string]string{{"id": "1"}, {"id": "2"}, {"id": "3"}})
+	s.Len(fresh, 2)
+	s.True(h.full())
+	s.Empty(h.add([]map[string]string{{"id": "4"}}))
+}
+
+func (s *HarvestSuite) Test_03_JSSyntax() {
+	checkJSSyntax(s.T(), map[string]string{"container": scrollContainerJS, "remain": scrollRemainJS})
+}
+
+func (s *HarvestSuite) Test_04_Selector() {
+	b := &Bot{}
+
+	for _, tt := range []struct {
+		sel  interface{}
+		want batchSelector
+	}{
+		{"div.feed article", batchSelector{CSS: "div.feed article"}},
+		{Selector{CSS: "article"}.ExactText("ad").IgnoreCase(), batchSelector{CSS: "article", Source: "^ad$", Flags: "i"}},
+		{&Selector{CSS: "article", Regex: "^a.$", CaseInsensitive: true}, batchSelector{CSS: "article", Source: "^a.$", Flags: "i"}},
+		{XPath("//article"), batchSelector{XPath: "//article"}},
+	} {
+		got, err := b.toBatchSelectors([]interface{}{tt.sel}, BotOpts{})
+		s.NoError(err)
+		s.Equal([]batchSelector{tt.want}, got)
+	}
+
+	_, err := b.toBatchSelectors([]interface{}{1}, BotOpts{})
+	s.ErrorIs(err, ErrorSelNotFound)
+}
@@ -1,6 +1,7 @@
 package xbot
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -14,6 +15,21 @@ import (
 )
 
 func NewDefaultLanucher(opts ...BotOptFunc) string {
+	u, err := NewDefaultLanucherE(opts...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("lauch failed")
+	}
+	return u
+}
+
+// NewDefaultLanucherE is same as NewDefaultLanucher, but returns ErrorLaunchFailed instead of log.Fatal
+func NewDefaultLanucherE(opts ...BotOptFunc) (string, error) {
+	_, u, err := newDefaultLauncherE(opts...)
+	return u, err
+}
+
+// newDefaultLauncherE launches browser, the launcher is returned to kill the browser if it cannot be connected
+func newDefaultLauncherE(opts ...BotOptFunc) (*launcher.Launcher, string, error) {
 	opt := BotOpts{
 		BotCfg: defaultCfg,
 	}
@@ -28,135 +44,236 @@ func NewDefaultLanucher(opts ...BotOptFunc) string {
 
 	// only default launcher require this
 	l = setLauncher(l, &opt)
-	loadProxy(l, cfg, opt)
+	loadProxy(l, cfg)
 
 	u, err := l.Launch()
 	if err != nil {
-		log.Fatal().Err(err).Msg("lauch failed")
+		return nil, "", newBotError(ErrorLaunchFailed, err)
 	}
-	return u
+	return l, u, nil
 }
 
-func loadProxyExtension(l *launcher.Launcher, cfg *BotConfig, opt BotOpts) {
-	if cfg.ProxyLine != "" {
-		dir := expandPath(cfg.ProxyRoot)
-		extensionFolder, _, _ := xutil.NewChromeExtension(cfg.ProxyLine, dir)
-		l.Set("load-extension", extensionFolder)
-		log.Info().Str("extension_folder", extensionFolder).Msg("load proxy extension")
-	}
-}
-
-func loadProxy(l *launcher.Launcher, cfg *BotConfig, opt BotOpts) {
+// loadProxy sets `--proxy-server` of browser,
+// the credentials of proxy line are answered by handleProxyAuth after browser connected
+func loadProxy(l *launcher.Launcher, cfg *BotConfig) {
 	if v := cfg.ProxyServer; v != "" {
 		l.Proxy(v)
 		log.Info().Str("server", v).Msg("load proxy server")
-	} else {
-		loadProxyExtension(l, cfg, opt)
+		return
+	}
+
+	if cfg.ProxyLine == "" {
+		return
+	}
+
+	pl, err := parseProxyLine(cfg.ProxyLine)
+	if err != nil {
+		log.Error().Err(err).Msg("skip invalid proxy line")
+		return
 	}
+	if pl.Scheme == proxySchemeSocks5 && pl.Username != "" {
+		log.Warn().Str("server", pl.Server()).Msg("chrome cannot authenticate socks5 proxy, credentials are ignored")
+	}
+
+	l.Proxy(pl.Server())
+	log.Info().Str("server", pl.Server()).Msg("load proxy line")
 }
 
 func newUserModeLauncher(cfg *BotConfig, opt BotOpts) string {
+	_, u, err := newUserModeLauncherE(cfg, opt)
+	if err != nil {
+		if errors.Is(err, ErrorProfileLocked) {
+			fmt.Printf("%[1]s\nlaunch chrome browser failed, please make sure it is closed, and then run again\n%[1]s\n", strings.Repeat("=", 32))
+			log.Fatal().Err(err).Msg("")
+		} else {
+			log.Fatal().Err(err).Msg("cannot launch browser")
+		}
+	}
+	return u
+}
+
+func newUserModeLauncherE(cfg *BotConfig, opt BotOpts) (*launcher.Launcher, string, error) {
 	l := launcher.NewUserMode()
 
-	loadProxy(l, cfg, opt)
+	loadProxy(l, cfg)
 
 	if b := cfg.Leakless; b {
 		l.Leakless(b)
 	}
 
 	u, err := l.UserDataDir(cfg.UserDataDir).Launch()
+	if isProfileLocked(err) {
+		return nil, "", newBotError(ErrorProfileLocked, err)
+	}
 	if err != nil {
-		s := fmt.Sprintf("%s", err)
-		if strings.Contains(s, "[launcher] Failed to get the debug url: Opening in existing browser session") {
-			fmt.Printf("%[1]s\nlaunch chrome browser failed, please make sure it is closed, and then run again\n%[1]s\n", strings.Repeat("=", 32))
-			log.Fatal().Err(err).Msg("")
-		} else {
-			log.Fatal().Err(err).Msg("cannot launch browser")
-		}
+		return nil, "", newBotError(ErrorLaunchFailed, err)
 	}
-	return u
+	return l, u, nil
 }
 
-func newRemoteLauncher(opt BotOpts) *launcher.Launcher {
-	l := launcher.MustNewManaged(opt.BotCfg.remoteServiceUrl)
+func newRemoteLauncher(opt BotOpts) (*launcher.Launcher, error) {
+	l, err := launcher.NewManaged(opt.BotCfg.remoteServiceUrl)
+	if err != nil {
+		return nil, err
+	}
 	l.Set("disable-gpu")
 	// Launch with headful mode
 	l.Headless(false).XVFB("--server-num=5", "--server-args=-screen 0 1600x900x16")
-	return l
+	return l, nil
 }
 
 func CustomizeBrowser(URL string, opts ...BotOptFunc) *rod.Browser {
+	brw, err := CustomizeBrowserE(URL, opts...)
+	if err != nil {
+		log.Fatal().Err(err).Str("url", URL).Msg("cannot connect browser")
+	}
+	return brw
+}
+
+// CustomizeBrowserE is same as CustomizeBrowser, but returns ErrorConnectFailed instead of log.Fatal
+func CustomizeBrowserE(URL string, opts ...BotOptFunc) (*rod.Browser, error) {
 	opt := BotOpts{
 		BotCfg: defaultCfg,
 	}
 	BindBotOpts(&opt, opts...)
 	cfg := opt.BotCfg
 
-	browser := rod.New().ControlURL(URL).MustConnect()
+	browser := rod.New().ControlURL(URL)
+	if err := browser.Connect(); err != nil {
+		return nil, newBotError(ErrorConnectFailed, err)
+	}
+
+	if err := handleProxyLineAuth(browser, cfg); err != nil {
+		_ = browser.Close()
+		return nil, newBotError(ErrorConnectFailed, err)
+	}
 
 	if cfg.NoDefaultDevice {
 		browser = browser.NoDefaultDevice()
 	}
 	if cfg.Incognito {
-		browser = browser.MustIncognito()
+		incognito, err := browser.Incognito()
+		if err != nil {
+			_ = browser.Close()
+			return nil, newBotError(ErrorConnectFailed, err)
+		}
+		browser = incognito
 	}
 
 	slow := xutil.AorB(cfg.SlowMotion, 500)
 	browser.SlowMotion(time.Millisecond * time.Duration(slow))
 	browser.Trace(cfg.Trace)
 
-	return browser
+	return browser, nil
 }
 
 func CustomizePage(brw *rod.Browser, opts ...BotOptFunc) *rod.Page {
+	page, err := CustomizePageE(brw, opts...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create page")
+	}
+	return page
+}
+
+// CustomizePageE is same as CustomizePage, but returns ErrorPageCreationFailed instead of log.Fatal
+func CustomizePageE(brw *rod.Browser, opts ...BotOptFunc) (*rod.Page, error) {
+	page, err := customizePage(brw, opts...)
+	if err != nil {
+		return nil, newBotError(ErrorPageCreationFailed, err)
+	}
+	return page, nil
+}
+
+func customizePage(brw *rod.Browser, opts ...BotOptFunc) (page *rod.Page, err error) {
 	opt := BotOpts{
 		BotCfg: defaultCfg,
 	}
 	BindBotOpts(&opt, opts...)
 	cfg := opt.BotCfg
 
-	var page *rod.Page
+	var pl *proxyLine
+	if opt.incognito {
+		incognito, proxy, e := newProxyContext(brw, opt.contextProxy)
+		if e != nil {
+			return nil, e
+		}
+		brw, pl = incognito, proxy
+		// dispose the context if page cannot be customized
+		defer func() {
+			if err != nil {
+				_ = incognito.Close()
+			}
+		}()
+	}
+
 	if cfg.WithStealth {
 		log.Warn().Msg("running with stealth.js")
-		page = stealth.MustPage(brw)
+		page, err = stealth.Page(brw)
+		if err != nil {
+			return nil, err
+		}
 
 		go brw.EachEvent(func(e *proto.TargetTargetCreated) {
-			if e.TargetInfo.Type != proto.TargetTargetInfoTypePage {
+			if e.TargetInfo.Type != proto.TargetTargetInfoTypePage || !inContext(brw, e.TargetInfo) {
 				return
 			}
-			brw.MustPageFromTargetID(e.TargetInfo.TargetID).MustEvalOnNewDocument(stealth.JS)
+			p, err := brw.PageFromTarget(e.TargetInfo.TargetID)
+			if err == nil {
+				_, err = p.EvalOnNewDocument(stealth.JS)
+			}
+			if err != nil {
+				log.Debug().Err(err).Msg("inject stealth.js")
+			}
 		})()
 	} else {
-		if opt.incognito {
-			page = brw.MustIncognito().MustPage()
-		} else {
-			page = brw.MustPage()
+		page, err = brw.Page(proto.TargetCreateTarget{})
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	if err = handleContextProxyAuth(brw, page, pl); err != nil {
+		return nil, err
+	}
+
 	if opt.BotCfg.UserAgent != "" {
 		ua := bindUA(opt.BotCfg.UserAgent)
-		page = page.MustSetUserAgent(ua)
+		if err = page.SetUserAgent(ua); err != nil {
+			return nil, err
+		}
 	}
 
 	if opt.incognito {
 		w, h := cfg.Width, cfg.Height
-		page = page.MustSetWindow(cfg.Left, cfg.Top, w, h)
-		return page
+		err = page.SetWindow(windowBounds(cfg.Left, cfg.Top, w, h))
+		return page, err
 	}
 
 	if cfg.Maximize {
-		page.MustWindowMaximize()
-		return page
+		err = page.SetWindow(&proto.BrowserBounds{WindowState: proto.BrowserWindowStateMaximized})
+		return page, err
 	}
 
 	w, h := cfg.Width, cfg.Height
 	vw := xutil.AorB(cfg.ViewOffsetWidth, 0)
 	vh := xutil.AorB(cfg.ViewOffsetHeight, 0)
-	page = page.MustSetWindow(cfg.Screen, 0, w, h)
+	if err = page.SetWindow(windowBounds(cfg.Screen, 0, w, h)); err != nil {
+		return nil, err
+	}
 	if vw != 0 || vh != 0 {
-		page = page.MustSetViewport(w-vw, h-vh, 0.0, false)
+		err = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{Width: w - vw, Height: h - vh})
 	}
 
-	return page
+	return page, err
+}
+
+// windowBounds is the same bounds used by rod.Page.MustSetWindow
+func windowBounds(left, top, width, height int) *proto.BrowserBounds {
+	return &proto.BrowserBounds{
+		Left:        &left,
+		Top:         &top,
+		Width:       &width,
+		Height:      &height,
+		WindowState: proto.BrowserWindowStateNormal,
+	}
 }
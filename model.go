@@ -1,6 +1,7 @@
 package xbot
 
 import (
+	"context"
 	"time"
 
 	"github.com/go-rod/rod"
@@ -20,12 +21,24 @@ type Box struct {
 type Bot struct {
 	panicBy BotPanicType
 
+	// ctx is bound by WithContext
+	ctx context.Context
+
 	longToSec   time.Duration
 	mediumToSec time.Duration
 	shortToSec  time.Duration
 	NapToSec    time.Duration
+	pageToSec   time.Duration
 
+	// popovers are bound by BindPopovers
 	popovers []string
+	// pagePopovers are of the registry page matching current url, see syncPopovers
+	pagePopovers []string
+
+	registry  *Registry
+	telemetry *SelectorTelemetry
+	network   *NetworkRecorder
+	har       *HARRecorder
 
 	LaunchURL string
 
@@ -45,6 +58,10 @@ type Bot struct {
 	ScrollAsHuman *ScrollAsHuman
 
 	UniqueID string
+
+	// Proxy is picked from proxyProvider when bot is created
+	Proxy         string
+	proxyProvider ProxyProvider
 }
 
 // BotConfig is used to config bot options, which is usually read from config file
@@ -65,7 +82,7 @@ type BotConfig struct {
 	Width  int `ini:"width"`
 	Height int `ini:"height"`
 	Left   int `ini:"left"`
-	Top    int `ini:"right"`
+	Top    int `ini:"top"`
 
 	ViewOffsetWidth  int `ini:"view_offset_width"`
 	ViewOffsetHeight int `ini:"view_offset_height"`
@@ -73,7 +90,11 @@ type BotConfig struct {
 	NoDefaultDevice bool `ini:"no_default_device"`
 
 	// ProxyRoot automatically created proxy saving path
+	//
+	// Deprecated: credentials of ProxyLine are answered by CDP, no proxy extension is created anymore
 	ProxyRoot string `ini:"proxy_root"`
+	// ProxyLine is with format `[scheme://]host:port:username:password:<OTHER>`,
+	// scheme is one of http (default), https, socks5
 	ProxyLine string `ini:"proxy_line"`
 
 	ProxyServer string `ini:"proxy_server"`
@@ -81,6 +102,14 @@ type BotConfig struct {
 
 	PageTimeout int `ini:"page_timeout"`
 
+	// timeout tiers in seconds, 0 will fallback to LongToSec/MediumToSec/ShortToSec/NapToSec
+	LongTimeout   int `ini:"long_timeout"`
+	MediumTimeout int `ini:"medium_timeout"`
+	ShortTimeout  int `ini:"short_timeout"`
+	NapTimeout    int `ini:"nap_timeout"`
+	// TimeoutScale multiplies all timeout tiers and PageTimeout at once, e.g. 2.0 on slow CI machines
+	TimeoutScale float64 `ini:"timeout_scale"`
+
 	// .rod->show
 	Headless bool `ini:"headless"`
 	// .rod->slow in milliseconds
@@ -105,7 +134,7 @@ type BotConfig struct {
 	// remoteServiceUrl
 	// two types:
 	//  - ws://ip:port this will launch remote browser
-	//  - ip:port this only connect to remote browser
+	//  - http://ip:port this only connect to remote browser
 	remoteServiceUrl string `ini:"remote_service_url"`
 
 	WithStealth bool `ini:"with_stealth"`
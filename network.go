@@ -0,0 +1,484 @@
+package xbot
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var (
+	ErrorNoResponseBody = errors.New("response body is not available")
+	ErrorNotRecording   = errors.New("network is not recording, call RecordNetwork before the action")
+)
+
+// RegexPrefix marks a url pattern is a regex, e.g. "regex=/api/v\d+/items", otherwise it's a glob like "*/api/*"
+const RegexPrefix = "regex="
+
+// DefaultNetworkEntries is the number of entries kept by NetworkRecorder, the oldest are dropped
+var DefaultNetworkEntries = 1000
+
+// compileURLPattern compiles glob (same as HijackRequests) or regex with RegexPrefix, empty matches all
+func compileURLPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, RegexPrefix) {
+		return regexp.Compile(strings.TrimPrefix(pattern, RegexPrefix))
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	return regexp.Compile(proto.PatternToReg(pattern))
+}
+
+// WebSocketFrame is a frame sent or received by a websocket
+type WebSocketFrame struct {
+	Sent      bool
+	Opcode    float64
+	Data      string
+	Timestamp proto.MonotonicTime
+}
+
+// NetworkEntry is a request recorded by NetworkRecorder with its response
+type NetworkEntry struct {
+	ID   proto.NetworkRequestID
+	Type proto.NetworkResourceType
+
+	Request *proto.NetworkRequest
+	// Response is nil until the response is received
+	Response *proto.NetworkResponse
+
+	// WallTime is when the request is sent
+	WallTime time.Time
+	// Started, Responded and Finished are browser's monotonic timestamps
+	Started   proto.MonotonicTime
+	Responded proto.MonotonicTime
+	Finished  proto.MonotonicTime
+
+	EncodedDataLength float64
+	// Failed is the error text if loading failed
+	Failed string
+	// Redirected is true if Response is a redirect, the redirected request is a new entry
+	Redirected bool
+
+	// Frames are the websocket frames
+	Frames []WebSocketFrame
+
+	done bool
+
+	rec *NetworkRecorder
+	src *NetworkEntry
+	// body is cached by Body
+	body []byte
+}
+
+func (e *NetworkEntry) URL() string {
+	if e.Request == nil {
+		return ""
+	}
+	return e.Request.URL
+}
+
+func (e *NetworkEntry) Method() string {
+	if e.Request == nil {
+		return ""
+	}
+	return e.Request.Method
+}
+
+// Status returns the status code, 0 if no response
+func (e *NetworkEntry) Status() int {
+	if e.Response == nil {
+		return 0
+	}
+	return e.Response.Status
+}
+
+// Done reports whether loading is finished or failed
+func (e *NetworkEntry) Done() bool {
+	return e.done
+}
+
+// Duration is the time from request sent to loading finished
+func (e *NetworkEntry) Duration() time.Duration {
+	if !e.done {
+		return 0
+	}
+	return (e.Finished - e.Started).Duration()
+}
+
+// Body returns the response body, it's only available before browser evicts it,
+// so read it soon or in the predicate of WaitForResponse
+func (e *NetworkEntry) Body() ([]byte, error) {
+	if e.rec == nil || e.src == nil {
+		return nil, ErrorNoResponseBody
+	}
+	return e.rec.body(e.src)
+}
+
+// JSON unmarshals the response body to v
+func (e *NetworkEntry) JSON(v interface{}) error {
+	raw, err := e.Body()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// snapshot copies e, so it can be read while recording goes on
+func (e *NetworkEntry) snapshot() *NetworkEntry {
+	cp := *e
+	cp.src = e
+	cp.body = nil
+	cp.Frames = append([]WebSocketFrame(nil), e.Frames...)
+	return &cp
+}
+
+// NetworkRecorder records requests and responses of a page by the Network domain events,
+// including fetch/xhr, documents and websocket frames, without hijacking requests
+type NetworkRecorder struct {
+	page *rod.Page
+
+	mu      sync.Mutex
+	entries []*NetworkEntry
+	byID    map[proto.NetworkRequestID]*NetworkEntry
+	limit   int
+	// captureBodies gets bodies once loading finished, before browser evicts them
+	captureBodies bool
+	// notify is closed and replaced on each update
+	notify chan struct{}
+
+	cancel context.CancelFunc
+}
+
+// NewNetworkRecorder starts recording page, limit is the number of entries to keep (DefaultNetworkEntries if 0)
+//
+// the Network domain is enabled explicitly and left enabled on Stop,
+// so stopping a recorder never disables it under another recorder of the same page
+func NewNetworkRecorder(page *rod.Page, limit int) *NetworkRecorder {
+	r := newNetworkRecorder(page, limit)
+
+	// EachEvent restores the domain only if it enabled it, which it won't as it's enabled here
+	_ = proto.NetworkEnable{}.Call(page)
+
+	ctx, cancel := context.WithCancel(page.GetContext())
+	r.cancel = cancel
+
+	wait := page.Context(ctx).EachEvent(
+		r.onRequest,
+		r.onResponse,
+		r.onFinished,
+		r.onFailed,
+		r.onWebSocketCreated,
+		r.onWebSocketClosed,
+		func(e *proto.NetworkWebSocketFrameSent) { r.onFrame(e.RequestID, e.Timestamp, e.Response, true) },
+		func(e *proto.NetworkWebSocketFrameReceived) { r.onFrame(e.RequestID, e.Timestamp, e.Response, false) },
+	)
+	go wait()
+
+	return r
+}
+
+func newNetworkRecorder(page *rod.Page, limit int) *NetworkRecorder {
+	if limit <= 0 {
+		limit = DefaultNetworkEntries
+	}
+	return &NetworkRecorder{
+		page:   page,
+		byID:   make(map[proto.NetworkRequestID]*NetworkEntry),
+		limit:  limit,
+		notify: make(chan struct{}),
+	}
+}
+
+// CaptureBodies gets response bodies as soon as loading finished,
+// otherwise they are got on demand by Body, which may fail after page navigated
+func (r *NetworkRecorder) CaptureBodies(enable bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.captureBodies = enable
+}
+
+// Stop stops recording, recorded entries are still available
+func (r *NetworkRecorder) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// Clear drops all recorded entries
+func (r *NetworkRecorder) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+	r.byID = make(map[proto.NetworkRequestID]*NetworkEntry)
+}
+
+// Entries returns all recorded entries in the order of requests sent
+func (r *NetworkRecorder) Entries() []*NetworkEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot()
+}
+
+// FindEntries returns entries whose url matches pattern and pred (if not nil), including unfinished ones
+func (r *NetworkRecorder) FindEntries(pattern string, pred func(e *NetworkEntry) bool) ([]*NetworkEntry, error) {
+	re, err := compileURLPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return filterEntries(r.Entries(), re, pred, false), nil
+}
+
+// FindResponses returns finished entries with response whose url matches pattern and pred (if not nil)
+func (r *NetworkRecorder) FindResponses(pattern string, pred func(e *NetworkEntry) bool) ([]*NetworkEntry, error) {
+	re, err := compileURLPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return filterEntries(r.Entries(), re, pred, true), nil
+}
+
+// WaitForResponse returns the first finished response whose url matches pattern and pred (if not nil),
+// recorded ones are included, call Clear before the action if only new responses are wanted
+func (r *NetworkRecorder) WaitForResponse(ctx context.Context, pattern string, pred func(e *NetworkEntry) bool) (*NetworkEntry, error) {
+	re, err := compileURLPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := map[*NetworkEntry]bool{}
+	for {
+		r.mu.Lock()
+		notify := r.notify
+		entries := r.snapshot()
+		r.mu.Unlock()
+
+		var fresh []*NetworkEntry
+		for _, e := range entries {
+			if e.done && !checked[e.src] {
+				checked[e.src] = true
+				fresh = append(fresh, e)
+			}
+		}
+
+		if found := filterEntries(fresh, re, pred, true); len(found) != 0 {
+			return found[0], nil
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func filterEntries(entries []*NetworkEntry, re *regexp.Regexp, pred func(e *NetworkEntry) bool, responded bool) []*NetworkEntry {
+	var found []*NetworkEntry
+	for _, e := range entries {
+		if responded && (!e.done || e.Response == nil) {
+			continue
+		}
+		if !re.MatchString(e.URL()) {
+			continue
+		}
+		if pred != nil && !pred(e) {
+			continue
+		}
+		found = append(found, e)
+	}
+	return found
+}
+
+func (r *NetworkRecorder) snapshot() []*NetworkEntry {
+	all := make([]*NetworkEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		all = append(all, e.snapshot())
+	}
+	return all
+}
+
+// update runs fn with the lock, and wakes up waiters
+func (r *NetworkRecorder) update(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+func (r *NetworkRecorder) add(e *NetworkEntry) {
+	e.rec = r
+	r.entries = append(r.entries, e)
+	r.byID[e.ID] = e
+
+	for len(r.entries) > r.limit {
+		old := r.entries[0]
+		r.entries = r.entries[1:]
+		if r.byID[old.ID] == old {
+			delete(r.byID, old.ID)
+		}
+	}
+}
+
+func (r *NetworkRecorder) onRequest(e *proto.NetworkRequestWillBeSent) {
+	r.update(func() {
+		// the redirected request reuses the id
+		if prev, ok := r.byID[e.RequestID]; ok && e.RedirectResponse != nil {
+			prev.Response, prev.Redirected = e.RedirectResponse, true
+			prev.Responded, prev.Finished, prev.done = e.Timestamp, e.Timestamp, true
+		}
+
+		r.add(&NetworkEntry{
+			ID:       e.RequestID,
+			Type:     e.Type,
+			Request:  e.Request,
+			WallTime: e.WallTime.Time(),
+			Started:  e.Timestamp,
+		})
+	})
+}
+
+func (r *NetworkRecorder) onResponse(e *proto.NetworkResponseReceived) {
+	r.update(func() {
+		if ent, ok := r.byID[e.RequestID]; ok {
+			ent.Response, ent.Responded = e.Response, e.Timestamp
+			if ent.Type == "" {
+				ent.Type = e.Type
+			}
+		}
+	})
+}
+
+func (r *NetworkRecorder) onFinished(e *proto.NetworkLoadingFinished) {
+	r.update(func() {
+		if ent, ok := r.byID[e.RequestID]; ok {
+			ent.Finished, ent.EncodedDataLength, ent.done = e.Timestamp, e.EncodedDataLength, true
+			if r.captureBodies && ent.Response != nil {
+				go func() { _, _ = r.body(ent) }()
+			}
+		}
+	})
+}
+
+func (r *NetworkRecorder) onFailed(e *proto.NetworkLoadingFailed) {
+	r.update(func() {
+		if ent, ok := r.byID[e.RequestID]; ok {
+			ent.Finished, ent.Failed, ent.done = e.Timestamp, e.ErrorText, true
+		}
+	})
+}
+
+func (r *NetworkRecorder) onWebSocketCreated(e *proto.NetworkWebSocketCreated) {
+	r.update(func() {
+		r.add(&NetworkEntry{
+			ID:       e.RequestID,
+			Type:     proto.NetworkResourceTypeWebSocket,
+			Request:  &proto.NetworkRequest{URL: e.URL, Method: "GET"},
+			WallTime: time.Now(),
+		})
+	})
+}
+
+func (r *NetworkRecorder) onWebSocketClosed(e *proto.NetworkWebSocketClosed) {
+	r.update(func() {
+		if ent, ok := r.byID[e.RequestID]; ok {
+			ent.Finished, ent.done = e.Timestamp, true
+		}
+	})
+}
+
+func (r *NetworkRecorder) onFrame(id proto.NetworkRequestID, ts proto.MonotonicTime, frame *proto.NetworkWebSocketFrame, sent bool) {
+	if frame == nil {
+		return
+	}
+	r.update(func() {
+		ent, ok := r.byID[id]
+		if !ok {
+			return
+		}
+		if ent.Started == 0 {
+			ent.Started = ts
+		}
+		ent.Frames = append(ent.Frames, WebSocketFrame{Sent: sent, Opcode: frame.Opcode, Data: frame.PayloadData, Timestamp: ts})
+	})
+}
+
+// body gets the response body of e from browser and caches it
+func (r *NetworkRecorder) body(e *NetworkEntry) ([]byte, error) {
+	r.mu.Lock()
+	cached, redirected, hasResponse := e.body, e.Redirected, e.Response != nil
+	r.mu.Unlock()
+
+	if cached != nil {
+		return cached, nil
+	}
+	if redirected || !hasResponse {
+		return nil, ErrorNoResponseBody
+	}
+
+	res, err := proto.NetworkGetResponseBody{RequestID: e.ID}.Call(r.page)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorNoResponseBody, err)
+	}
+
+	raw := []byte(res.Body)
+	if res.Base64Encoded {
+		if raw, err = base64.StdEncoding.DecodeString(res.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	e.body = raw
+	r.mu.Unlock()
+	return raw, nil
+}
+
+// RecordNetwork starts recording network of current page, and stops the previous recorder of bot
+func (b *Bot) RecordNetwork() *NetworkRecorder {
+	if b.network != nil {
+		b.network.Stop()
+	}
+	b.network = NewNetworkRecorder(b.Pg, 0)
+	return b.network
+}
+
+// NetworkRecorder returns the recorder started by RecordNetwork, nil if not started
+func (b *Bot) NetworkRecorder() *NetworkRecorder {
+	return b.network
+}
+
+// FindResponses finds responses recorded by bot's recorder, see NetworkRecorder.FindResponses
+func (b *Bot) FindResponses(pattern string, pred func(e *NetworkEntry) bool) ([]*NetworkEntry, error) {
+	if b.network == nil {
+		return nil, nil
+	}
+	return b.network.FindResponses(pattern, pred)
+}
+
+// WaitForResponse waits a response recorded by bot's recorder up to BotTimeout, see NetworkRecorder.WaitForResponse,
+// the recording must start before the action which sends the request, otherwise ErrorNotRecording is returned
+//
+//	b.RecordNetwork()
+//	b.MustScrollAndClick("button.search")
+//	e, err := b.WaitForResponse("*/api/search*", func(e *NetworkEntry) bool { return e.Status() == 200 })
+func (b *Bot) WaitForResponse(pattern string, pred func(e *NetworkEntry) bool, opts ...BotOptFunc) (*NetworkEntry, error) {
+	opt := BotOpts{Timeout: toSec(b.mediumToSec)}
+	BindBotOpts(&opt, opts...)
+
+	if b.network == nil {
+		return nil, ErrorNotRecording
+	}
+
+	ctx, cancel := context.WithTimeout(b.Context(), time.Duration(opt.Timeout)*time.Second)
+	defer cancel()
+	return b.network.WaitForResponse(ctx, pattern, pred)
+}
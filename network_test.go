@@ -0,0 +1,185 @@
+package xbot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/suite"
+)
+
+type NetworkSuite struct {
+	suite.Suite
+}
+
+func TestNetwork(t *testing.T) {
+	suite.Run(t, new(NetworkSuite))
+}
+
+func sendRequest(r *NetworkRecorder, id, url string, ts float64) {
+	r.onRequest(&proto.NetworkRequestWillBeSent{
+		RequestID: proto.NetworkRequestID(id),
+		Request:   &proto.NetworkRequest{URL: url, Method: "GET"},
+		Type:      proto.NetworkResourceTypeFetch,
+		Timestamp: proto.MonotonicTime(ts),
+	})
+}
+
+func respond(r *NetworkRecorder, id string, status int, ts float64) {
+	r.onResponse(&proto.NetworkResponseReceived{
+		RequestID: proto.NetworkRequestID(id),
+		Response:  &proto.NetworkResponse{Status: status},
+		Timestamp: proto.MonotonicTime(ts),
+	})
+	r.onFinished(&proto.NetworkLoadingFinished{RequestID: proto.NetworkRequestID(id), Timestamp: proto.MonotonicTime(ts + 1)})
+}
+
+func (s *NetworkSuite) Test_01_Pattern() {
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"", "https://a.com/x", true},
+		{"*/api/*", "https://a.com/api/items?p=1", true},
+		{"*/api/*", "https://a.com/static/app.js", false},
+		{`regex=/api/v\d+/`, "https://a.com/api/v2/items", true},
+		{`regex=/api/v\d+/`, "https://a.com/api/vx/items", false},
+	}
+	for _, tt := range tests {
+		re, err := compileURLPattern(tt.pattern)
+		s.Require().NoError(err)
+		s.Equal(tt.want, re.MatchString(tt.url), tt.pattern)
+	}
+}
+
+func (s *NetworkSuite) Test_02_Record() {
+	r := newNetworkRecorder(nil, 0)
+
+	sendRequest(r, "1", "https://a.com/old", 1)
+	// redirect reuses the request id
+	r.onRequest(&proto.NetworkRequestWillBeSent{
+		RequestID:        "1",
+		Request:          &proto.NetworkRequest{URL: "https://a.com/api/items", Method: "GET"},
+		RedirectResponse: &proto.NetworkResponse{Status: 302},
+		Timestamp:        2,
+	})
+	respond(r, "1", 200, 3)
+
+	sendRequest(r, "2", "https://a.com/api/broken", 5)
+	r.onFailed(&proto.NetworkLoadingFailed{RequestID: "2", ErrorText: "net::ERR_FAILED", Timestamp: 6})
+
+	sendRequest(r, "3", "https://a.com/api/pending", 7)
+
+	all := r.Entries()
+	s.Require().Len(all, 4)
+	s.True(all[0].Redirected)
+	s.Equal(302, all[0].Status())
+	s.Equal(200, all[1].Status())
+	s.Equal(2*time.Second, all[1].Duration())
+	s.Equal("net::ERR_FAILED", all[2].Failed)
+	s.False(all[3].Done())
+
+	_, err := all[0].Body()
+	s.True(errors.Is(err, ErrorNoResponseBody))
+
+	found, err := r.FindResponses("*/api/*", nil)
+	s.NoError(err)
+	s.Require().Len(found, 1)
+	s.Equal("https://a.com/api/items", found[0].URL())
+
+	found, err = r.FindEntries("*/api/*", nil)
+	s.NoError(err)
+	s.Len(found, 3)
+
+	found, err = r.FindResponses("", func(e *NetworkEntry) bool { return e.Status() >= 300 })
+	s.NoError(err)
+	s.Len(found, 1)
+}
+
+func (s *NetworkSuite) Test_03_WebSocket() {
+	r := newNetworkRecorder(nil, 0)
+	r.onWebSocketCreated(&proto.NetworkWebSocketCreated{RequestID: "ws", URL: "wss://a.com/live"})
+	r.onFrame("ws", 1, &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: "ping"}, true)
+	r.onFrame("ws", 2, &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: "pong"}, false)
+	r.onWebSocketClosed(&proto.NetworkWebSocketClosed{RequestID: "ws", Timestamp: 3})
+
+	found, err := r.FindEntries("wss://*", nil)
+	s.NoError(err)
+	s.Require().Len(found, 1)
+	s.Equal([]WebSocketFrame{
+		{Sent: true, Opcode: 1, Data: "ping", Timestamp: 1},
+		{Sent: false, Opcode: 1, Data: "pong", Timestamp: 2},
+	}, found[0].Frames)
+	s.True(found[0].Done())
+}
+
+func (s *NetworkSuite) Test_04_Wait() {
+	r := newNetworkRecorder(nil, 0)
+	sendRequest(r, "1", "https://a.com/api/items", 1)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		respond(r, "1", 500, 2)
+		sendRequest(r, "2", "https://a.com/api/items", 3)
+		respond(r, "2", 200, 4)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	e, err := r.WaitForResponse(ctx, "*/api/items", func(e *NetworkEntry) bool { return e.Status() == 200 })
+	s.Require().NoError(err)
+	s.Equal(proto.NetworkRequestID("2"), e.ID)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = r.WaitForResponse(ctx, "*/none", nil)
+	s.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func (s *NetworkSuite) Test_05_Limit() {
+	r := newNetworkRecorder(nil, 2)
+	for _, id := range []string{"1", "2", "3"} {
+		sendRequest(r, id, "https://a.com/"+id, 1)
+	}
+
+	all := r.Entries()
+	s.Require().Len(all, 2)
+	s.Equal("https://a.com/2", all[0].URL())
+	s.NotContains(r.byID, proto.NetworkRequestID("1"))
+
+	r.Clear()
+	s.Empty(r.Entries())
+}
+
+func (s *NetworkSuite) Test_06_NotRecording() {
+	_, err := (&Bot{}).WaitForResponse("*/api/*", nil, BotTimeout(1))
+	s.ErrorIs(err, ErrorNotRecording)
+}
+
+// Test_07_StackedRecorders stops an older recorder while a newer one of the same page is still recording
+func (s *NetworkSuite) Test_07_StackedRecorders() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body>ok</body></html>`))
+	}))
+	defer srv.Close()
+
+	b, err := NewBotE(BotUserAgent(UA), BotHeadless(true))
+	if err != nil {
+		s.T().Skipf("browser not available: %v", err)
+	}
+	defer b.Close()
+
+	b.StartHAR()
+	b.RecordNetwork()
+	s.Require().NoError(b.StopHAR(""))
+
+	s.Require().NoError(b.GetPageE(srv.URL + "/after"))
+	e, err := b.WaitForResponse("*/after", nil, BotTimeout(5))
+	s.Require().NoError(err)
+	s.Equal(200, e.Status())
+}
@@ -12,7 +12,18 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func NewBot(opts ...BotOptFunc) (bot *Bot) {
+// NewBot creates a bot, it panics if any error, see NewBotE
+func NewBot(opts ...BotOptFunc) *Bot {
+	bot, err := NewBotE(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return bot
+}
+
+// NewBotE creates a bot, and returns error instead of panic or log.Fatal,
+// the error can be checked by errors.Is with ErrorLaunchFailed/ErrorProfileLocked/ErrorConnectFailed/ErrorPageCreationFailed
+func NewBotE(opts ...BotOptFunc) (*Bot, error) {
 	opt := BotOpts{
 		spawn:   true,
 		panicBy: PanicByLogFatal,
@@ -21,19 +32,35 @@ func NewBot(opts ...BotOptFunc) (bot *Bot) {
 	BindBotOpts(&opt, opts...)
 
 	if !opt.BotCfg.UserMode && opt.BotCfg.UserAgent == "" {
-		panic(`UserAgent is required, please use xbot.BotUserAgent(ua) to bind it;
-and you can visit https://www.whatismyip.com/user-agent/ to check your user-agent`)
+		return nil, ErrorUserAgentRequired
 	}
 
-	bot = new(Bot)
+	proxy, opts, err := pickProxy(opt, opts)
+	if err != nil {
+		return nil, err
+	}
+	BindBotOpts(&opt, opts...)
+
+	if err := opt.BotCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	bot := new(Bot)
 	bot.Config = opt.BotCfg
+	bot.Proxy, bot.proxyProvider = proxy, opt.proxyProvider
+	bot.UseRegistry(opt.registry)
+	bot.telemetry = opt.telemetry
 	if opt.spawn {
-		bot.LaunchURL, bot.Brw, bot.Pg = createBrwAndPage(opts...)
+		u, brw, page, err := createBrwAndPageE(opts...)
+		if err != nil {
+			return nil, err
+		}
+		bot.LaunchURL, bot.Brw, bot.Pg = u, brw, page
 	}
 	bot.SetTimeout()
 	bot.UniqueID = strutil.RandomCharsV3(8)
 
-	return bot
+	return bot, nil
 }
 
 func NewBotWithPage(page *rod.Page, opts ...BotOptFunc) *Bot {
@@ -45,8 +72,10 @@ func NewBotWithPage(page *rod.Page, opts ...BotOptFunc) *Bot {
 	BindBotOpts(&opt, opts...)
 
 	bot := &Bot{
-		Pg:     page,
-		Config: opt.BotCfg,
+		Pg:        page,
+		Config:    opt.BotCfg,
+		registry:  opt.registry,
+		telemetry: opt.telemetry,
 	}
 
 	bot.SetTimeout()
@@ -54,6 +83,23 @@ func NewBotWithPage(page *rod.Page, opts ...BotOptFunc) *Bot {
 	return bot
 }
 
+// NewIncognitoBot creates a bot whose page is in a new incognito context of brw,
+// and the context egresses through its own proxy, so a single browser can serve many bots
+// each with a different proxy, empty proxy uses the browser-wide proxy.
+//
+// bot.Close only disposes the incognito context, brw is kept open
+func NewIncognitoBot(brw *rod.Browser, proxy string, opts ...BotOptFunc) (*Bot, error) {
+	page, err := CustomizePageE(brw, append(opts[:len(opts):len(opts)], Incognito(true), WithContextProxy(proxy))...)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := NewBotWithPage(page, opts...)
+	bot.Brw = page.Browser()
+	bot.Proxy = proxy
+	return bot, nil
+}
+
 // NewDefaultBot creates a bot with default configs
 func NewDefaultBot(spawn bool) *Bot {
 	return NewBot(BotSpawn(spawn), BotUserAgent(UA))
@@ -94,17 +140,25 @@ func SpawnBrowserOnly(bot *Bot, opts ...BotOptFunc) {
 }
 
 func createBrwAndPage(opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.Page) {
+	URL, brw, page, err := createBrwAndPageE(opts...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create browser and page")
+	}
+	return
+}
+
+func createBrwAndPageE(opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.Page, err error) {
 	opt := BotOpts{BotCfg: defaultCfg}
 	BindBotOpts(&opt, opts...)
 
 	if opt.BotCfg.remoteServiceUrl != "" {
-		return NewRemoteBrwAndPage(opts...)
+		return NewRemoteBrwAndPageE(opts...)
 	}
 
 	if opt.BotCfg.UserMode {
-		return NewUserModeBrwAndPage(opts...)
+		return NewUserModeBrwAndPageE(opts...)
 	}
-	return NewBrwAndPage(opts...)
+	return NewBrwAndPageE(opts...)
 }
 
 // NewBrwAndPage create and return a Browser and a blank page with window size 1366*768
@@ -121,6 +175,22 @@ func NewBrwAndPage(opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.
 	return u, brw, page
 }
 
+// NewBrwAndPageE is same as NewBrwAndPage, but returns error instead of log.Fatal,
+// the browser is closed if it is connected but page cannot be created.
+func NewBrwAndPageE(opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.Page, err error) {
+	l, u, err := newDefaultLauncherE(opts...)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	URL, brw, page, err = connectBrwAndPageE(u, opts...)
+	if err != nil {
+		// the launched browser is useless if it cannot be connected
+		l.Kill()
+	}
+	return URL, brw, page, err
+}
+
 // NewUserModeBrwAndPage run with user mode, will use system browser.
 //
 // we can integrate this with NewBrwAndPage, but there are too many if-else,
@@ -143,7 +213,54 @@ func NewUserModeBrwAndPage(opts ...BotOptFunc) (URL string, brw *rod.Browser, pa
 	return u, brw, page
 }
 
+// NewUserModeBrwAndPageE is same as NewUserModeBrwAndPage, but returns error instead of log.Fatal,
+// when the profile is used by another running browser, ErrorProfileLocked is returned,
+// so caller can retry later or fallback to another UserDataDir
+func NewUserModeBrwAndPageE(opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.Page, err error) {
+	opt := BotOpts{
+		BotCfg: defaultCfg,
+	}
+	BindBotOpts(&opt, opts...)
+	cfg := opt.BotCfg
+
+	l, u, err := newUserModeLauncherE(cfg, opt)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	brw, err = CustomizeBrowserE(u, opts...)
+	if err != nil {
+		l.Kill()
+		return "", nil, nil, err
+	}
+
+	if cfg.ClearCookies {
+		log.Info().Msg("clear cookies in user-mode")
+		if err = brw.SetCookies(nil); err != nil {
+			_ = brw.Close()
+			return "", nil, nil, newBotError(ErrorConnectFailed, err)
+		}
+	}
+
+	page, err = CustomizePageE(brw, opts...)
+	if err != nil {
+		_ = brw.Close()
+		return "", nil, nil, err
+	}
+
+	return u, brw, page, nil
+}
+
 func NewRemoteBrwAndPage(opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.Page) {
+	URL, brw, page, err := NewRemoteBrwAndPageE(opts...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create remote browser and page")
+	}
+	return
+}
+
+// NewRemoteBrwAndPageE is same as NewRemoteBrwAndPage, but returns error instead of panic
+func NewRemoteBrwAndPageE(opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.Page, err error) {
 	opt := BotOpts{
 		BotCfg: defaultCfg,
 	}
@@ -151,15 +268,50 @@ func NewRemoteBrwAndPage(opts ...BotOptFunc) (URL string, brw *rod.Browser, page
 	cfg := opt.BotCfg
 
 	if strings.HasPrefix(cfg.remoteServiceUrl, "ws://") {
-		l := newRemoteLauncher(opt)
-		brw = rod.New().Client(l.MustClient()).MustConnect()
+		l, e := newRemoteLauncher(opt)
+		if e != nil {
+			return "", nil, nil, newBotError(ErrorLaunchFailed, e)
+		}
+		client, e := l.Client()
+		if e != nil {
+			return "", nil, nil, newBotError(ErrorConnectFailed, e)
+		}
+		brw = rod.New().Client(client)
 	} else {
-		u := launcher.MustResolveURL(cfg.remoteServiceUrl)
-		brw = rod.New().ControlURL(u).MustConnect()
+		u, e := launcher.ResolveURL(cfg.remoteServiceUrl)
+		if e != nil {
+			return "", nil, nil, newBotError(ErrorConnectFailed, e)
+		}
+		brw = rod.New().ControlURL(u)
+	}
+
+	if err = brw.Connect(); err != nil {
+		return "", nil, nil, newBotError(ErrorConnectFailed, err)
+	}
+
+	page, err = CustomizePageE(brw, opts...)
+	if err != nil {
+		_ = brw.Close()
+		return "", nil, nil, err
+	}
+
+	return "", brw, page, nil
+}
+
+// connectBrwAndPageE connects to the launched browser URL and creates the customized page
+func connectBrwAndPageE(u string, opts ...BotOptFunc) (URL string, brw *rod.Browser, page *rod.Page, err error) {
+	brw, err = CustomizeBrowserE(u, opts...)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	page, err = CustomizePageE(brw, opts...)
+	if err != nil {
+		_ = brw.Close()
+		return "", nil, nil, err
 	}
-	page = CustomizePage(brw, opts...)
 
-	return "", brw, page
+	return u, brw, page, nil
 }
 
 func setLauncher(l *launcher.Launcher, opt *BotOpts) *launcher.Launcher {
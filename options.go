@@ -1,6 +1,11 @@
 package xbot
 
-import "github.com/go-rod/rod"
+import (
+	"fmt"
+
+	"github.com/coghost/xutil"
+	"github.com/go-rod/rod"
+)
 
 type BotOpts struct {
 	spawn   bool
@@ -34,8 +39,22 @@ type BotOpts struct {
 	retry int
 
 	root *rod.Element
+	// iframes to search in, each one is nested in the previous one
+	frames []frameSpec
 
 	incognito bool
+
+	// browsers launched by BotPool
+	poolBrowsers int
+
+	proxyProvider ProxyProvider
+	proxyDomain   string
+
+	// proxy of the incognito context
+	contextProxy string
+
+	registry  *Registry
+	telemetry *SelectorTelemetry
 }
 
 type BotOptFunc func(o *BotOpts)
@@ -77,7 +96,10 @@ func BotUserAgent(s string) BotOptFunc {
 	}
 }
 
-// BotProxyLine is with format `host:port:username:password:<OTHER>`
+// BotProxyLine is with format `[scheme://]host:port:username:password:<OTHER>`,
+// scheme is one of http (default), https, socks5
+//
+//	e.g. BotProxyLine("socks5://127.0.0.1:1080:user:pass")
 func BotProxyLine(s string) BotOptFunc {
 	return func(o *BotOpts) {
 		o.BotCfg.ProxyLine = s
@@ -164,6 +186,32 @@ func BotTimeout(i int) BotOptFunc {
 	}
 }
 
+// WithTimeouts sets the timeout tiers in seconds, 0 keeps the current value
+func WithTimeouts(long, medium, short, nap int) BotOptFunc {
+	return func(o *BotOpts) {
+		o.BotCfg.LongTimeout = xutil.AorB(long, o.BotCfg.LongTimeout)
+		o.BotCfg.MediumTimeout = xutil.AorB(medium, o.BotCfg.MediumTimeout)
+		o.BotCfg.ShortTimeout = xutil.AorB(short, o.BotCfg.ShortTimeout)
+		o.BotCfg.NapTimeout = xutil.AorB(nap, o.BotCfg.NapTimeout)
+	}
+}
+
+// WithPageTimeout sets the timeout in seconds of GetPage/GetPageE
+func WithPageTimeout(i int) BotOptFunc {
+	return func(o *BotOpts) {
+		o.BotCfg.PageTimeout = i
+	}
+}
+
+// WithTimeoutScale multiplies all timeout tiers and page timeout,
+//
+//	e.g. WithTimeoutScale(2) on slow CI machines or proxies
+func WithTimeoutScale(f float64) BotOptFunc {
+	return func(o *BotOpts) {
+		o.BotCfg.TimeoutScale = f
+	}
+}
+
 func WithPanicBy(i BotPanicType) BotOptFunc {
 	return func(o *BotOpts) {
 		o.panicBy = i
@@ -176,6 +224,17 @@ func BindBotOpts(opt *BotOpts, opts ...BotOptFunc) {
 	}
 }
 
+// bindSelector applies CaseInsensitive and root of opts to sel
+func (o *BotOpts) bindSelector(sel Selector) Selector {
+	if o.CaseInsensitive {
+		sel.CaseInsensitive = true
+	}
+	if sel.Root == nil {
+		sel.Root = o.root
+	}
+	return sel
+}
+
 func WithSleepSec(t float64) BotOptFunc {
 	return func(o *BotOpts) {
 		o.sleepSecBeforeAction = t
@@ -226,8 +285,72 @@ func WithRoot(root *rod.Element) BotOptFunc {
 	}
 }
 
+// WithFrame searches elements inside the iframe found by sel (string or Selector),
+// call it multiple times for nested iframes
+func WithFrame(sel interface{}) BotOptFunc {
+	return func(o *BotOpts) {
+		o.frames = append(o.frames, frameSpec{sel: sel})
+	}
+}
+
+// WithFrameName is WithFrame by the name attribute of iframe
+func WithFrameName(name string) BotOptFunc {
+	return WithFrame(fmt.Sprintf(`iframe[name=%q], frame[name=%q]`, name, name))
+}
+
+// WithFrameURL is WithFrame by the iframe whose url contains s
+func WithFrameURL(s string) BotOptFunc {
+	return func(o *BotOpts) {
+		o.frames = append(o.frames, frameSpec{url: s})
+	}
+}
+
+// WithRegistry binds the page registry to bot, see Bot.UseRegistry
+func WithRegistry(r *Registry) BotOptFunc {
+	return func(o *BotOpts) {
+		o.registry = r
+	}
+}
+
+// WithSelectorTelemetry shares t among bots, so lookups of SelectorChain are reported together
+func WithSelectorTelemetry(t *SelectorTelemetry) BotOptFunc {
+	return func(o *BotOpts) {
+		o.telemetry = t
+	}
+}
+
 func Incognito(b bool) BotOptFunc {
 	return func(o *BotOpts) {
 		o.incognito = b
 	}
 }
+
+// WithPoolBrowsers sets how many browsers are launched by NewBotPool
+func WithPoolBrowsers(i int) BotOptFunc {
+	return func(o *BotOpts) {
+		o.poolBrowsers = i
+	}
+}
+
+// WithProxyProvider makes NewBot/NewBotPool pick proxy from provider before launching browser,
+// and bot reports the proxy's failures back to provider
+func WithProxyProvider(p ProxyProvider) BotOptFunc {
+	return func(o *BotOpts) {
+		o.proxyProvider = p
+	}
+}
+
+// WithProxyDomain is the domain passed to ProxyProvider.Next, used by StickyProxyProvider
+func WithProxyDomain(s string) BotOptFunc {
+	return func(o *BotOpts) {
+		o.proxyDomain = s
+	}
+}
+
+// WithContextProxy sets the proxy of incognito context created with Incognito(true),
+// proxy is a proxy line `[scheme://]host:port:username:password` or a proxy server `[scheme://]host:port`
+func WithContextProxy(s string) BotOptFunc {
+	return func(o *BotOpts) {
+		o.contextProxy = s
+	}
+}
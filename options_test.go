@@ -155,3 +155,13 @@ func (s *BotOptsSuite) TestBotTimeout() {
 		s.Equal(tt.want, s.opt.Timeout, tt.want)
 	}
 }
+
+func (s *BotOptsSuite) TestWithFrame() {
+	opt := BotOpts{}
+	BindBotOpts(&opt, WithFrame("iframe#login"), WithFrameName("inner"), WithFrameURL("/captcha"))
+
+	s.Len(opt.frames, 3)
+	s.Equal("iframe#login", opt.frames[0].String())
+	s.Equal(`iframe[name="inner"], frame[name="inner"]`, opt.frames[1].String())
+	s.Equal("url=/captcha", opt.frames[2].String())
+}
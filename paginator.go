@@ -0,0 +1,389 @@
+package xbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrorInvalidPaginator = errors.New("invalid paginator")
+
+// PaginateStop is the reason why a paginator stopped
+type PaginateStop string
+
+const (
+	StopNone         PaginateStop = ""
+	StopMaxPages     PaginateStop = "max pages reached"
+	StopNoNewItems   PaginateStop = "no new items"
+	StopNextNotFound PaginateStop = "next not found"
+	StopNextDisabled PaginateStop = "next disabled"
+	StopError        PaginateStop = "error"
+)
+
+// nextDisabledJS reports whether the next button (or its wrapper like li.disabled) is disabled
+const nextDisabledJS = `function() {
+	return this.disabled === true || !!this.closest("[disabled], .disabled, [aria-disabled=true]")
+}`
+
+// pageMarkJS marks current document and records the time of its last DOM mutation,
+// the mark is gone when the page navigates to a new document
+const pageMarkJS = `() => {
+	const mark = {changed: false, last: 0}
+	new MutationObserver(() => {
+		mark.changed = true
+		mark.last = Date.now()
+	}).observe(document.documentElement, {childList: true, subtree: true, characterData: true})
+	window.__xbotPageMark = mark
+}`
+
+// pageIdleJS returns the milliseconds since the DOM changed after pageMarkJS, or -1 if unchanged,
+// a new document counts as changed once it's loaded
+const pageIdleJS = `(settle) => {
+	const mark = window.__xbotPageMark
+	if (!mark) return document.readyState === "complete" ? settle : -1
+	return mark.changed ? Date.now() - mark.last : -1
+}`
+
+// pageSettle is how long the DOM must stay unchanged after turning before the page is seen as ready
+const pageSettle = 500 * time.Millisecond
+
+// ItemExtractor extracts items of current page, e.g. with b.Extract or b.GetElemsAttrs
+type ItemExtractor func(b *Bot) ([]interface{}, error)
+
+// PaginatedPage is a page yielded by Paginator
+type PaginatedPage struct {
+	// Number starts from 1, or the start page of WithPageURL
+	Number int
+	URL    string
+	// Items are the new items of the page after de-duplication
+	Items []interface{}
+	// Extracted is the number of items extracted before de-duplication
+	Extracted int
+}
+
+type PaginatorOpts struct {
+	next     interface{}
+	nextOpts []BotOptFunc
+
+	urlTemplate string
+	startPage   int
+
+	maxPages int
+	// noNewLimit stops after pages without new items in a row, 0 never stops
+	noNewLimit   int
+	itemKey      func(item interface{}) string
+	clickRetries int
+	wait         func(b *Bot) error
+}
+
+type PaginatorOptFunc func(o *PaginatorOpts)
+
+func BindPaginatorOpts(opt *PaginatorOpts, opts ...PaginatorOptFunc) {
+	for _, f := range opts {
+		f(opt)
+	}
+}
+
+// WithNextButton turns pages by clicking selector, opts are used to get the button
+func WithNextButton(selector interface{}, opts ...BotOptFunc) PaginatorOptFunc {
+	return func(o *PaginatorOpts) {
+		o.next = selector
+		o.nextOpts = opts
+	}
+}
+
+// WithPageURL turns pages by opening template formatted with page number, e.g. "https://example.com/list?page=%d"
+func WithPageURL(template string, start int) PaginatorOptFunc {
+	return func(o *PaginatorOpts) {
+		o.urlTemplate = template
+		o.startPage = start
+	}
+}
+
+// WithMaxPages stops after n pages, 0 means no limit
+func WithMaxPages(n int) PaginatorOptFunc {
+	return func(o *PaginatorOpts) {
+		o.maxPages = n
+	}
+}
+
+// WithNoNewItemsLimit stops after n pages without new items in a row (1 by default), 0 never stops
+func WithNoNewItemsLimit(n int) PaginatorOptFunc {
+	return func(o *PaginatorOpts) {
+		o.noNewLimit = n
+	}
+}
+
+// WithItemKey sets the key to de-duplicate items, items are compared by their json by default
+func WithItemKey(fn func(item interface{}) string) PaginatorOptFunc {
+	return func(o *PaginatorOpts) {
+		o.itemKey = fn
+	}
+}
+
+// WithClickRetries sets the tries to find and click the next button (3 by default)
+func WithClickRetries(n int) PaginatorOptFunc {
+	return func(o *PaginatorOpts) {
+		o.clickRetries = n
+	}
+}
+
+// WithPageWait waits the next page ready after turning.
+// By default page load is waited WithPageURL, and WithNextButton waits the DOM to change then settle,
+// so AJAX pagination which doesn't navigate works too
+func WithPageWait(fn func(b *Bot) error) PaginatorOptFunc {
+	return func(o *PaginatorOpts) {
+		o.wait = fn
+	}
+}
+
+// Paginator repeats "extract, turn to next page, wait" until a stop condition meets
+//
+//	p, _ := b.NewPaginator(extract, WithNextButton("a.next"), WithMaxPages(10))
+//	for p.Next() {
+//		save(p.Page().Items)
+//	}
+//	if err := p.Err(); err != nil { ... }
+type Paginator struct {
+	bot     *Bot
+	extract ItemExtractor
+	opt     PaginatorOpts
+
+	seen   map[string]struct{}
+	number int
+	noNew  int
+
+	page *PaginatedPage
+	stop PaginateStop
+	err  error
+
+	// turnPage is p.turn, replaceable in tests
+	turnPage func() (PaginateStop, error)
+}
+
+// NewPaginator creates a paginator which starts from current page,
+// or from the start page WithPageURL
+func (b *Bot) NewPaginator(extract ItemExtractor, opts ...PaginatorOptFunc) (*Paginator, error) {
+	opt := PaginatorOpts{startPage: 1, noNewLimit: 1, clickRetries: 3}
+	BindPaginatorOpts(&opt, opts...)
+
+	if extract == nil {
+		return nil, fmt.Errorf("%w: extractor is required", ErrorInvalidPaginator)
+	}
+	if (opt.next == nil) == (opt.urlTemplate == "") {
+		return nil, fmt.Errorf("%w: either WithNextButton or WithPageURL is required", ErrorInvalidPaginator)
+	}
+	if opt.urlTemplate != "" && !strings.Contains(opt.urlTemplate, "%d") {
+		return nil, fmt.Errorf("%w: url template %q has no %%d", ErrorInvalidPaginator, opt.urlTemplate)
+	}
+	if opt.itemKey == nil {
+		opt.itemKey = defaultItemKey
+	}
+
+	p := &Paginator{bot: b, extract: extract, opt: opt, seen: make(map[string]struct{})}
+	p.turnPage = p.turn
+	return p, nil
+}
+
+// Next turns to the next page and extracts its items, it returns false when stopped,
+// check Err and StopReason then
+func (p *Paginator) Next() bool {
+	if p.stop != StopNone {
+		return false
+	}
+
+	if p.opt.maxPages > 0 && p.number >= p.opt.maxPages {
+		return p.halt(StopMaxPages, nil)
+	}
+
+	if stop, err := p.turnPage(); stop != StopNone {
+		return p.halt(stop, err)
+	}
+	p.number++
+
+	items, err := p.extract(p.bot)
+	if err != nil {
+		return p.halt(StopError, err)
+	}
+
+	fresh := p.dedup(items)
+	if len(fresh) == 0 {
+		p.noNew++
+		if p.opt.noNewLimit > 0 && p.noNew >= p.opt.noNewLimit {
+			return p.halt(StopNoNewItems, nil)
+		}
+	} else {
+		p.noNew = 0
+	}
+
+	p.page = &PaginatedPage{Number: p.pageNumber(), URL: p.currentURL(), Items: fresh, Extracted: len(items)}
+	return true
+}
+
+// Page returns the current page
+func (p *Paginator) Page() *PaginatedPage {
+	return p.page
+}
+
+// Err returns the error which stopped the paginator
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// StopReason returns why the paginator stopped, StopNone if it's not stopped
+func (p *Paginator) StopReason() PaginateStop {
+	return p.stop
+}
+
+// Each calls fn with each page until stopped or fn returns error
+func (p *Paginator) Each(fn func(page *PaginatedPage) error) error {
+	for p.Next() {
+		if err := fn(p.page); err != nil {
+			return err
+		}
+	}
+	return p.err
+}
+
+// All returns the items of all pages
+func (p *Paginator) All() ([]interface{}, error) {
+	var all []interface{}
+	err := p.Each(func(page *PaginatedPage) error {
+		all = append(all, page.Items...)
+		return nil
+	})
+	return all, err
+}
+
+func (p *Paginator) halt(stop PaginateStop, err error) bool {
+	p.stop, p.err = stop, err
+	log.Debug().Err(err).Int("pages", p.number).Str("reason", string(stop)).Msg("paginator stopped")
+	return false
+}
+
+func (p *Paginator) pageNumber() int {
+	if p.opt.urlTemplate != "" {
+		return p.opt.startPage + p.number - 1
+	}
+	return p.number
+}
+
+func (p *Paginator) currentURL() string {
+	if p.bot.Pg == nil {
+		return ""
+	}
+	info, err := p.bot.Pg.Timeout(p.bot.NapToSec).Info()
+	if err != nil {
+		return ""
+	}
+	return info.URL
+}
+
+// turn opens the next page, the first page is current page in next button mode
+func (p *Paginator) turn() (PaginateStop, error) {
+	if p.opt.urlTemplate != "" {
+		url := fmt.Sprintf(p.opt.urlTemplate, p.opt.startPage+p.number)
+		if err := p.bot.GetPageE(url); err != nil {
+			return StopError, err
+		}
+		return p.wait(func() error {
+			return p.bot.Pg.Timeout(p.bot.pageToSec).WaitLoad()
+		})
+	}
+
+	if p.number == 0 {
+		return StopNone, nil
+	}
+
+	b := p.bot
+	elem := b.GetElem(p.opt.next, p.opt.nextOpts...)
+	if elem == nil {
+		if e := b.ctxErr(); e != nil {
+			return StopError, e
+		}
+		return StopNextNotFound, nil
+	}
+
+	if obj, err := elem.Timeout(b.shortToSec).Eval(nextDisabledJS); err == nil && obj.Value.Bool() {
+		return StopNextDisabled, nil
+	}
+
+	if _, err := b.Pg.Timeout(b.shortToSec).Eval(pageMarkJS); err != nil {
+		return StopError, err
+	}
+
+	// the button is re-got on each try, in case it's re-rendered
+	_, err := b.RetryWhenPanic(func() {
+		elem := b.RecalculateElem(p.opt.next, p.opt.nextOpts...)
+		if elem == nil {
+			panic(ErrorSelNotFound)
+		}
+		b.MustScrollAndClickElem(elem)
+	}, p.opt.clickRetries)
+	if err != nil {
+		return StopError, err
+	}
+
+	return p.wait(p.waitChanged)
+}
+
+// wait runs WithPageWait if set, or the default wait of the turning mode
+func (p *Paginator) wait(fallback func() error) (PaginateStop, error) {
+	var err error
+	if p.opt.wait != nil {
+		err = p.opt.wait(p.bot)
+	} else {
+		err = fallback()
+	}
+
+	if err != nil {
+		return StopError, err
+	}
+	return StopNone, nil
+}
+
+// waitChanged waits the DOM marked before clicking to change and then settle for pageSettle,
+// a page which never changes in pageToSec is left to the no-new-items check
+func (p *Paginator) waitChanged() error {
+	b := p.bot
+	settle := pageSettle.Milliseconds()
+	deadline := time.Now().Add(b.pageToSec)
+
+	for time.Now().Before(deadline) {
+		// eval fails while navigating, just poll again
+		if obj, err := b.Pg.Timeout(b.shortToSec).Eval(pageIdleJS, settle); err == nil && obj.Value.Int() >= int(settle) {
+			return b.Pg.Timeout(b.pageToSec).WaitLoad()
+		}
+		if err := b.randSleep(0.2, 0.3); err != nil {
+			return err
+		}
+	}
+
+	log.Debug().Int("page", p.number).Msg("page not changed after clicking next")
+	return nil
+}
+
+// dedup returns items not seen before
+func (p *Paginator) dedup(items []interface{}) []interface{} {
+	var fresh []interface{}
+	for _, item := range items {
+		key := p.opt.itemKey(item)
+		if _, ok := p.seen[key]; ok {
+			continue
+		}
+		p.seen[key] = struct{}{}
+		fresh = append(fresh, item)
+	}
+	return fresh
+}
+
+func defaultItemKey(item interface{}) string {
+	if raw, err := json.Marshal(item); err == nil {
+		return string(raw)
+	}
+	return fmt.Sprintf("%#v", item)
+}
@@ -0,0 +1,153 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PaginatorSuite struct {
+	suite.Suite
+}
+
+func TestPaginator(t *testing.T) {
+	suite.Run(t, new(PaginatorSuite))
+}
+
+func noItems(*Bot) ([]interface{}, error) { return nil, nil }
+
+func (s *PaginatorSuite) Test_01_New() {
+	b := &Bot{}
+
+	tests := []struct {
+		name    string
+		extract ItemExtractor
+		opts    []PaginatorOptFunc
+		wantErr bool
+	}{
+		{"no extractor", nil, []PaginatorOptFunc{WithNextButton("a.next")}, true},
+		{"no turning", noItems, nil, true},
+		{"both turning", noItems, []PaginatorOptFunc{WithNextButton("a.next"), WithPageURL("/p/%d", 1)}, true},
+		{"template without %d", noItems, []PaginatorOptFunc{WithPageURL("/p", 1)}, true},
+		{"next button", noItems, []PaginatorOptFunc{WithNextButton("a.next")}, false},
+		{"url", noItems, []PaginatorOptFunc{WithPageURL("/p/%d", 2)}, false},
+	}
+
+	for _, tt := range tests {
+		_, err := b.NewPaginator(tt.extract, tt.opts...)
+		if tt.wantErr {
+			s.True(errors.Is(err, ErrorInvalidPaginator), tt.name)
+		} else {
+			s.NoError(err, tt.name)
+		}
+	}
+}
+
+func (s *PaginatorSuite) Test_02_Dedup() {
+	p, err := (&Bot{}).NewPaginator(noItems, WithNextButton("a.next"))
+	s.Require().NoError(err)
+
+	s.Equal([]interface{}{"a", "b"}, p.dedup([]interface{}{"a", "b", "a"}))
+	s.Equal([]interface{}{"c"}, p.dedup([]interface{}{"b", "c"}))
+	s.Nil(p.dedup([]interface{}{"a", "c"}))
+
+	type item struct{ ID int }
+	s.Equal(defaultItemKey(item{1}), defaultItemKey(item{1}))
+	s.NotEqual(defaultItemKey(item{1}), defaultItemKey(item{2}))
+	s.Equal(defaultItemKey(map[string]string{"a": "1", "b": "2"}), defaultItemKey(map[string]string{"b": "2", "a": "1"}))
+
+	p, err = (&Bot{}).NewPaginator(noItems, WithNextButton("a.next"), WithItemKey(func(v interface{}) string {
+		return fmt.Sprint(v.(item).ID)
+	}))
+	s.Require().NoError(err)
+	s.Len(p.dedup([]interface{}{item{1}, item{1}, item{2}}), 2)
+}
+
+func (s *PaginatorSuite) Test_03_Stop() {
+	p, err := (&Bot{}).NewPaginator(noItems, WithPageURL("/p/%d", 3), WithMaxPages(2))
+	s.Require().NoError(err)
+	s.Equal(StopNone, p.StopReason())
+
+	p.number = 2
+	s.Equal(4, p.pageNumber())
+	s.False(p.Next())
+	s.Equal(StopMaxPages, p.StopReason())
+	s.NoError(p.Err())
+
+	items, err := p.All()
+	s.NoError(err)
+	s.Empty(items)
+}
+
+func (s *PaginatorSuite) Test_04_Next() {
+	// pages of fake items, page 3 has nothing new
+	pages := [][]interface{}{{"a", "b"}, {"b", "c"}, {"a", "c"}, {}, {"d"}}
+	extract := func(p **Paginator) ItemExtractor {
+		return func(*Bot) ([]interface{}, error) {
+			return pages[(*p).number-1], nil
+		}
+	}
+
+	tests := []struct {
+		name   string
+		opts   []PaginatorOptFunc
+		want   [][]interface{}
+		stop   PaginateStop
+		number int
+	}{
+		{"no new once", nil, [][]interface{}{{"a", "b"}, {"c"}}, StopNoNewItems, 3},
+		{"no new twice", []PaginatorOptFunc{WithNoNewItemsLimit(2)}, [][]interface{}{{"a", "b"}, {"c"}, nil}, StopNoNewItems, 4},
+		{"never stop on no new", []PaginatorOptFunc{WithNoNewItemsLimit(0)}, [][]interface{}{{"a", "b"}, {"c"}, nil, nil, {"d"}}, StopMaxPages, 5},
+		{"max pages", []PaginatorOptFunc{WithMaxPages(1)}, [][]interface{}{{"a", "b"}}, StopMaxPages, 1},
+	}
+
+	for _, tt := range tests {
+		var p *Paginator
+		opts := append([]PaginatorOptFunc{WithNextButton("a.next"), WithMaxPages(len(pages))}, tt.opts...)
+		p, err := (&Bot{}).NewPaginator(extract(&p), opts...)
+		s.Require().NoError(err)
+
+		turned := 0
+		p.turnPage = func() (PaginateStop, error) {
+			turned++
+			return StopNone, nil
+		}
+
+		var got [][]interface{}
+		for p.Next() {
+			got = append(got, p.Page().Items)
+			s.Equal(p.number, p.Page().Number, tt.name)
+		}
+
+		s.Equal(tt.want, got, tt.name)
+		s.Equal(tt.stop, p.StopReason(), tt.name)
+		s.Equal(tt.number, p.number, tt.name)
+		s.Equal(tt.number, turned, tt.name)
+		s.NoError(p.Err(), tt.name)
+	}
+}
+
+func (s *PaginatorSuite) Test_05_NextStops() {
+	boom := errors.New("boom")
+
+	p, err := (&Bot{}).NewPaginator(noItems, WithNextButton("a.next"))
+	s.Require().NoError(err)
+	p.turnPage = func() (PaginateStop, error) { return StopNextDisabled, nil }
+	s.False(p.Next())
+	s.Equal(StopNextDisabled, p.StopReason())
+	s.Equal(0, p.number)
+
+	p, err = (&Bot{}).NewPaginator(func(*Bot) ([]interface{}, error) { return nil, boom }, WithNextButton("a.next"))
+	s.Require().NoError(err)
+	p.turnPage = func() (PaginateStop, error) { return StopNone, nil }
+	s.False(p.Next())
+	s.Equal(StopError, p.StopReason())
+	s.ErrorIs(p.Err(), boom)
+	s.False(p.Next())
+}
+
+func (s *PaginatorSuite) Test_06_JSSyntax() {
+	checkJSSyntax(s.T(), map[string]string{"next": nextDisabledJS, "mark": pageMarkJS, "idle": pageIdleJS})
+}
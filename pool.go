@@ -0,0 +1,320 @@
+package xbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coghost/xutil"
+	"github.com/go-rod/rod"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrorPoolClosed = errors.New("bot pool is closed")
+
+// BotPool owns one or more browsers, and hands out bots by Acquire/Release.
+//
+// each bot is backed by a tab of the browsers (reused after Release),
+// or by an isolated incognito context with Incognito(true) (disposed after Release),
+// and at most size bots are handed out at the same time.
+//
+// with WithProxyProvider, each browser picks a proxy at launch,
+// or each incognito context picks its own proxy with Incognito(true).
+//
+// WARN: bots from pool should be returned by Release, bot.Close will close the whole browser in tab mode
+type BotPool struct {
+	opts          []BotOptFunc
+	incognito     bool
+	proxyProvider ProxyProvider
+	proxyDomain   string
+
+	// slots caps the concurrency
+	slots chan struct{}
+
+	mu     sync.Mutex
+	brws   []*poolBrowser
+	next   int
+	idle   []*Bot
+	inUse  map[*Bot]struct{}
+	closed bool
+}
+
+// poolBrowser is a browser launched by BotPool with the proxy it uses
+type poolBrowser struct {
+	*rod.Browser
+	proxy string
+}
+
+// NewBotPool launches WithPoolBrowsers(n) browsers (1 by default),
+// and allows at most size bots to be acquired at the same time
+//
+//	e.g. NewBotPool(4, BotUserAgent(UA), Incognito(true), WithPoolBrowsers(2))
+func NewBotPool(size int, opts ...BotOptFunc) (*BotPool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("pool size should be greater than 0, got %d", size)
+	}
+
+	opt := BotOpts{
+		BotCfg:       defaultCfg,
+		poolBrowsers: 1,
+	}
+	BindBotOpts(&opt, opts...)
+
+	if !opt.BotCfg.UserMode && opt.BotCfg.UserAgent == "" {
+		return nil, ErrorUserAgentRequired
+	}
+	if err := opt.BotCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &BotPool{
+		opts:          opts,
+		incognito:     opt.incognito,
+		proxyProvider: opt.proxyProvider,
+		proxyDomain:   opt.proxyDomain,
+		slots:         make(chan struct{}, size),
+		inUse:         make(map[*Bot]struct{}),
+	}
+
+	// in incognito mode, proxy is picked for each context instead of each browser
+	brwOpt := opt
+	if opt.incognito {
+		brwOpt.proxyProvider = nil
+	}
+
+	for i := 0; i < xutil.Max(opt.poolBrowsers, 1); i++ {
+		// each browser may egress through a different proxy of provider
+		proxy, brwOpts, err := pickProxy(brwOpt, opts)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+
+		l, u, err := newDefaultLauncherE(brwOpts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+
+		brw, err := CustomizeBrowserE(u, brwOpts...)
+		if err != nil {
+			l.Kill()
+			p.Close()
+			return nil, err
+		}
+		p.brws = append(p.brws, &poolBrowser{Browser: brw, proxy: proxy})
+	}
+
+	return p, nil
+}
+
+// Acquire waits until a slot is free or ctx is done, then returns a healthy bot,
+// crashed idle bots are recycled and replaced by new ones.
+func (p *BotPool) Acquire(ctx context.Context) (*Bot, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	bot, err := p.acquire()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	return bot, nil
+}
+
+func (p *BotPool) acquire() (*Bot, error) {
+	for {
+		bot, err := p.popIdle()
+		if err != nil {
+			return nil, err
+		}
+		if bot == nil {
+			break
+		}
+		if p.healthy(bot) {
+			return p.markInUse(bot)
+		}
+		log.Debug().Str("bot", bot.UniqueID).Msg("recycle crashed bot")
+		p.recycle(bot)
+	}
+
+	bot, err := p.newBot()
+	if err != nil {
+		return nil, err
+	}
+	return p.markInUse(bot)
+}
+
+// Release returns the bot to pool, the bot should not be used anymore after released
+func (p *BotPool) Release(bot *Bot) {
+	if bot == nil {
+		return
+	}
+
+	p.mu.Lock()
+	_, ok := p.inUse[bot]
+	delete(p.inUse, bot)
+	p.mu.Unlock()
+
+	if !ok {
+		log.Warn().Str("bot", bot.UniqueID).Msg("release bot not acquired from this pool")
+		return
+	}
+	defer func() { <-p.slots }()
+
+	if p.incognito || !p.healthy(bot) || p.resetBot(bot) != nil {
+		p.recycle(bot)
+		return
+	}
+
+	p.mu.Lock()
+	closed := p.closed
+	if !closed {
+		p.idle = append(p.idle, bot)
+	}
+	p.mu.Unlock()
+
+	// recycle talks to browser, so it's done out of lock
+	if closed {
+		p.recycle(bot)
+	}
+}
+
+// Stats returns how many bots are idle and in use
+func (p *BotPool) Stats() (idle, inUse int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle), len(p.inUse)
+}
+
+// Close closes all idle bots and browsers, bots in use are closed with their browsers
+func (p *BotPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle, brws := p.idle, p.brws
+	p.idle, p.brws = nil, nil
+	p.mu.Unlock()
+
+	for _, bot := range idle {
+		p.recycle(bot)
+	}
+	for _, brw := range brws {
+		if err := brw.Close(); err != nil {
+			log.Debug().Err(err).Msg("close pool browser")
+		}
+	}
+}
+
+func (p *BotPool) popIdle() (*Bot, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrorPoolClosed
+	}
+	if len(p.idle) == 0 {
+		return nil, nil
+	}
+
+	bot := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return bot, nil
+}
+
+func (p *BotPool) markInUse(bot *Bot) (*Bot, error) {
+	p.mu.Lock()
+	closed := p.closed
+	if !closed {
+		p.inUse[bot] = struct{}{}
+	}
+	p.mu.Unlock()
+
+	// recycle talks to browser, so it's done out of lock
+	if closed {
+		p.recycle(bot)
+		return nil, ErrorPoolClosed
+	}
+	return bot, nil
+}
+
+func (p *BotPool) nextBrowser() (*poolBrowser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || len(p.brws) == 0 {
+		return nil, ErrorPoolClosed
+	}
+	brw := p.brws[p.next%len(p.brws)]
+	p.next++
+	return brw, nil
+}
+
+func (p *BotPool) newBot() (*Bot, error) {
+	pb, err := p.nextBrowser()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, opts := pb.proxy, append(p.opts[:len(p.opts):len(p.opts)], Incognito(p.incognito))
+	if p.incognito && p.proxyProvider != nil {
+		proxy, err = p.proxyProvider.Next(p.proxyDomain)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithContextProxy(proxy))
+	}
+
+	page, err := CustomizePageE(pb.Browser, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := NewBotWithPage(page, p.opts...)
+	// the incognito context when Incognito(true)
+	bot.Brw = page.Browser()
+	bot.Proxy, bot.proxyProvider = proxy, p.proxyProvider
+	return bot, nil
+}
+
+// healthy checks the page of bot is still responsive
+func (p *BotPool) healthy(bot *Bot) bool {
+	if bot.Pg == nil {
+		return false
+	}
+	_, err := bot.Pg.Timeout(bot.NapToSec).Eval(`() => true`)
+	return err == nil
+}
+
+// resetBot clears the states of a released bot, so it can be reused in tab mode
+func (p *BotPool) resetBot(bot *Bot) error {
+	if bot.PrevPage != nil {
+		_ = bot.Pg.Close()
+		bot.Pg, bot.PrevPage = bot.PrevPage, nil
+	}
+	bot.ResetRoot()
+	bot.BindPopovers(nil)
+	bot.Iframe = nil
+	return bot.Pg.Timeout(bot.shortToSec).Navigate("about:blank")
+}
+
+// recycle closes the pages of bot, and disposes its incognito context if any
+func (p *BotPool) recycle(bot *Bot) {
+	for _, pg := range []*rod.Page{bot.Pg, bot.PrevPage} {
+		if pg != nil {
+			_ = pg.Close()
+		}
+	}
+
+	if bot.Brw != nil && bot.Brw.BrowserContextID != "" {
+		_ = bot.Brw.Close()
+	}
+}
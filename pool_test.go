@@ -0,0 +1,137 @@
+package xbot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coghost/xbot"
+	"github.com/stretchr/testify/suite"
+)
+
+type poolSuite struct {
+	suite.Suite
+}
+
+func TestBotPool(t *testing.T) {
+	suite.Run(t, new(poolSuite))
+}
+
+func (s *poolSuite) Test_01_InvalidSize() {
+	_, err := xbot.NewBotPool(0, xbot.BotUserAgent(xbot.UA))
+	s.NotNil(err)
+}
+
+func (s *poolSuite) Test_02_AcquireRelease() {
+	for _, incognito := range []bool{false, true} {
+		pool, err := xbot.NewBotPool(2, xbot.BotUserAgent(xbot.UA), xbot.Incognito(incognito))
+		s.Nil(err)
+
+		b1, err := pool.Acquire(context.Background())
+		s.Nil(err)
+		b2, err := pool.Acquire(context.Background())
+		s.Nil(err)
+		s.NotEqual(b1.Pg.TargetID, b2.Pg.TargetID)
+
+		idle, inUse := pool.Stats()
+		s.Equal(0, idle)
+		s.Equal(2, inUse)
+
+		// pool is full, so acquire should wait until ctx is done
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		_, err = pool.Acquire(ctx)
+		cancel()
+		s.ErrorIs(err, context.DeadlineExceeded)
+
+		pool.Release(b1)
+		b3, err := pool.Acquire(context.Background())
+		s.Nil(err)
+
+		// crashed page should be recycled
+		s.Nil(b3.Pg.Close())
+		pool.Release(b3)
+		pool.Release(b2)
+
+		b4, err := pool.Acquire(context.Background())
+		s.Nil(err)
+		s.Nil(b4.GetPageE("about:blank"))
+		pool.Release(b4)
+
+		pool.Close()
+		_, err = pool.Acquire(context.Background())
+		s.ErrorIs(err, xbot.ErrorPoolClosed)
+	}
+}
+
+func (s *poolSuite) Test_03_IncognitoBotWithProxy() {
+	// a local proxy stand-in, which answers every proxied request by itself
+	var proxied []string
+	var mu sync.Mutex
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.Host)
+		mu.Unlock()
+		_, _ = w.Write([]byte("<html><body><div id=proxied>proxied</div></body></html>"))
+	}))
+	defer stub.Close()
+
+	b := xbot.NewBot(xbot.BotUserAgent(xbot.UA), xbot.BotHeadless(true))
+	defer b.Close()
+
+	proxy := strings.TrimPrefix(stub.URL, "http://")
+	ib, err := xbot.NewIncognitoBot(b.Brw, proxy, xbot.BotUserAgent(xbot.UA))
+	s.Nil(err)
+	defer ib.Close()
+	s.Equal(proxy, ib.Proxy)
+	s.NotEmpty(ib.Brw.BrowserContextID)
+
+	s.Nil(ib.GetPageE("http://xbot.example/"))
+	s.Equal("proxied", ib.GetElementAttr("div#proxied"))
+
+	mu.Lock()
+	s.Contains(proxied, "xbot.example")
+	mu.Unlock()
+}
+
+func (s *poolSuite) Test_04_IncognitoStealthWithProxyAuth() {
+	// a local proxy stand-in, which asks for credentials before answering
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") == "" {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="xbot"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		_, _ = w.Write([]byte("<html><body><div id=proxied>" + r.Host + "</div></body></html>"))
+	}))
+	defer stub.Close()
+
+	b := xbot.NewBot(xbot.BotUserAgent(xbot.UA), xbot.BotHeadless(true))
+	defer b.Close()
+
+	cfg := xbot.NewDefaultBotCfg()
+	cfg.UserAgent, cfg.WithStealth = xbot.UA, true
+
+	proxy := strings.TrimPrefix(stub.URL, "http://") + ":u:p"
+	ib, err := xbot.NewIncognitoBot(b.Brw, proxy, xbot.WithBotConfig(cfg))
+	s.Nil(err)
+	defer ib.Close()
+	s.NotEmpty(ib.Brw.BrowserContextID, "stealth page should be in the incognito context")
+
+	s.Nil(ib.GetPageE("http://xbot.example/"))
+	s.Equal("xbot.example", ib.GetElementAttr("div#proxied"))
+
+	// popups of the context are authenticated too
+	wait := ib.Pg.WaitOpen()
+	_, err = ib.Pg.Eval(`() => window.open("http://popup.example/")`)
+	s.Nil(err)
+	popup, err := wait()
+	s.Nil(err)
+	s.Nil(popup.Timeout(10 * time.Second).WaitLoad())
+	el, err := popup.Timeout(10 * time.Second).Element("div#proxied")
+	s.Nil(err)
+	s.Equal("popup.example", el.MustText())
+}
@@ -61,6 +61,12 @@ func NewDefaultBotCfg() *BotConfig {
 		Width:       1366,
 		Height:      728,
 
+		LongTimeout:   LongToSec,
+		MediumTimeout: MediumToSec,
+		ShortTimeout:  ShortToSec,
+		NapTimeout:    NapToSec,
+		TimeoutScale:  1,
+
 		NoDefaultDevice: true,
 		Incognito:       false,
 
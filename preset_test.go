@@ -40,6 +40,12 @@ func (s *PresetSuite) Test_01_new() {
 		Width:       1366,
 		Height:      728,
 
+		LongTimeout:   LongToSec,
+		MediumTimeout: MediumToSec,
+		ShortTimeout:  ShortToSec,
+		NapTimeout:    NapToSec,
+		TimeoutScale:  1,
+
 		PerInputLength: 7,
 	}
 
@@ -55,3 +61,28 @@ func (s *PresetSuite) Test_02_new() {
 	}
 	dump.P(b.longToSec)
 }
+
+func (s *PresetSuite) Test_03_SetTimeout() {
+	b := &Bot{Config: NewDefaultBotCfg()}
+	b.SetTimeout()
+	s.Equal(LongToSec*time.Second, b.longToSec)
+	s.Equal(MediumToSec*time.Second, b.mediumToSec)
+	s.Equal(ShortToSec*time.Second, b.shortToSec)
+	s.Equal(NapToSec*time.Second, b.NapToSec)
+	s.Equal(60*time.Second, b.pageToSec)
+
+	opt := BotOpts{BotCfg: NewDefaultBotCfg()}
+	BindBotOpts(&opt, WithTimeouts(30, 0, 4, 1), WithPageTimeout(90), WithTimeoutScale(1.5))
+	b = &Bot{Config: opt.BotCfg}
+	b.SetTimeout()
+	s.Equal(45*time.Second, b.longToSec)
+	s.Equal(30*time.Second, b.mediumToSec, "0 keeps the default tier")
+	s.Equal(6*time.Second, b.shortToSec)
+	s.Equal(1500*time.Millisecond, b.NapToSec)
+	s.Equal(135*time.Second, b.pageToSec)
+	s.Equal(2, toSec(b.NapToSec))
+
+	b = &Bot{Config: &BotConfig{}}
+	b.SetTimeout()
+	s.Equal(MediumToSec*time.Second, b.mediumToSec, "empty config falls back to the constants")
+}
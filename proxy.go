@@ -0,0 +1,171 @@
+package xbot
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	proxySchemeHTTP   = "http"
+	proxySchemeHTTPS  = "https"
+	proxySchemeSocks5 = "socks5"
+
+	// maxAnsweredAuth limits the request ids remembered by the proxy auth of Router
+	maxAnsweredAuth = 1024
+)
+
+// proxyLine is parsed from format `[scheme://]host:port:username:password:<OTHER>`
+type proxyLine struct {
+	Scheme   string
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+func parseProxyLine(line string) (*proxyLine, error) {
+	raw := strings.TrimSpace(line)
+
+	scheme := proxySchemeHTTP
+	if i := strings.Index(raw, "://"); i >= 0 {
+		scheme, raw = strings.ToLower(raw[:i]), raw[i+3:]
+	}
+	switch scheme {
+	case proxySchemeHTTP, proxySchemeHTTPS, proxySchemeSocks5:
+	default:
+		return nil, fmt.Errorf("proxy_line %q has unsupported scheme %q", line, scheme)
+	}
+
+	arr := strings.Split(raw, ":")
+	if len(arr) < 4 {
+		return nil, fmt.Errorf("proxy_line %q should be host:port:username:password", line)
+	}
+
+	pl := &proxyLine{Scheme: scheme, Host: arr[0], Port: arr[1], Username: arr[2], Password: arr[3]}
+	if pl.Host == "" {
+		return nil, fmt.Errorf("proxy_line %q has empty host", line)
+	}
+	if p, err := strconv.Atoi(pl.Port); err != nil || p <= 0 || p > 65535 {
+		return nil, fmt.Errorf("proxy_line %q has invalid port %q", line, pl.Port)
+	}
+
+	return pl, nil
+}
+
+// Server returns `scheme://host:port` used by `--proxy-server`
+func (p *proxyLine) Server() string {
+	return p.Scheme + "://" + net.JoinHostPort(p.Host, p.Port)
+}
+
+// handleProxyLineAuth answers proxy authentication of all pages in brw with credentials of cfg.ProxyLine,
+// ProxyServer has higher priority, so nothing is done if it is set
+func handleProxyLineAuth(brw *rod.Browser, cfg *BotConfig) error {
+	if cfg.ProxyServer != "" || cfg.ProxyLine == "" {
+		return nil
+	}
+
+	pl, err := parseProxyLine(cfg.ProxyLine)
+	if err != nil {
+		return err
+	}
+	if pl.Username == "" || pl.Scheme == proxySchemeSocks5 {
+		return nil
+	}
+
+	return handleProxyAuth(brw, pl.Username, pl.Password)
+}
+
+// newProxyContext creates an incognito browser context which egresses through proxy,
+// proxy is a proxy line or a proxy server, empty proxy uses the browser-wide proxy.
+//
+// the returned proxyLine is nil if proxy is not a proxy line
+func newProxyContext(brw *rod.Browser, proxy string) (*rod.Browser, *proxyLine, error) {
+	req := proto.TargetCreateBrowserContext{ProxyServer: proxy}
+
+	pl, err := parseProxyLine(proxy)
+	if err == nil {
+		req.ProxyServer = pl.Server()
+	} else {
+		pl = nil
+	}
+
+	res, err := req.Call(brw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// same as rod.Browser.Incognito, but with proxy
+	incognito := *brw
+	incognito.BrowserContextID = res.BrowserContextID
+
+	return &incognito, pl, nil
+}
+
+// handleContextProxyAuth answers proxy authentication with credentials of pl,
+// for page and for every page opened later in its context brw, e.g. new tabs and popups.
+//
+// pages opened later are handled once their targets are created, it stops when page is closed
+func handleContextProxyAuth(brw *rod.Browser, page *rod.Page, pl *proxyLine) error {
+	if pl == nil || pl.Username == "" || pl.Scheme == proxySchemeSocks5 {
+		return nil
+	}
+
+	if err := handleProxyAuth(page, pl.Username, pl.Password); err != nil {
+		return err
+	}
+
+	go brw.EachEvent(
+		func(e *proto.TargetTargetCreated) bool {
+			info := e.TargetInfo
+			if info.Type != proto.TargetTargetInfoTypePage || info.TargetID == page.TargetID || !inContext(brw, info) {
+				return false
+			}
+			p, err := brw.PageFromTarget(info.TargetID)
+			if err == nil {
+				err = handleProxyAuth(p, pl.Username, pl.Password)
+			}
+			if err != nil {
+				log.Debug().Err(err).Str("target", string(info.TargetID)).Msg("handle proxy auth of new page")
+			}
+			return false
+		},
+		func(e *proto.TargetTargetDestroyed) bool {
+			return e.TargetID == page.TargetID
+		},
+	)()
+
+	return nil
+}
+
+// inContext reports whether target belongs to the browser context of brw, any target belongs to the default one
+func inContext(brw *rod.Browser, info *proto.TargetTargetInfo) bool {
+	return brw.BrowserContextID == "" || info.BrowserContextID == brw.BrowserContextID
+}
+
+// fetchTarget is rod.Browser or rod.Page
+type fetchTarget interface {
+	proto.Client
+	proto.Contextable
+}
+
+// handleProxyAuth answers each Fetch.authRequired from proxy with the credentials,
+// works in headless mode and writes no files.
+//
+// the Router of target owns the Fetch domain, it continues unmatched requests as is,
+// so routes and proxy auth work together, see Router.authResponse for rejected credentials.
+//
+// the target can be a browser to handle all pages, or a single page
+func handleProxyAuth(target fetchTarget, username, password string) error {
+	r, err := routerOf(target)
+	if err != nil {
+		return err
+	}
+	r.setProxyAuth(username, password)
+	return nil
+}
@@ -0,0 +1,324 @@
+package xbot
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrorNoProxyAvailable = errors.New("no proxy available, all proxies are benched")
+
+// ProxyFailure is the reason reported to ProxyProvider
+type ProxyFailure int
+
+const (
+	// ProxyConnRefused proxy cannot be connected
+	ProxyConnRefused ProxyFailure = iota + 1
+	// ProxyAuthRejected proxy responses 407
+	ProxyAuthRejected
+	// ProxyCaptcha target site shows captcha through the proxy
+	ProxyCaptcha
+)
+
+// weight is how many failures the reason counts for,
+// rejected auth won't recover by itself, so the proxy is benched for the longest cooldown at once
+func (f ProxyFailure) weight() int {
+	if f == ProxyAuthRejected {
+		return maxProxyBackoff + 1
+	}
+	return 1
+}
+
+func (f ProxyFailure) String() string {
+	switch f {
+	case ProxyConnRefused:
+		return "connection refused"
+	case ProxyAuthRejected:
+		return "auth rejected"
+	case ProxyCaptcha:
+		return "captcha detected"
+	}
+	return "unknown"
+}
+
+// DefaultProxyCooldown is how long a failed proxy is benched at first,
+// the cooldown doubles with each consecutive failure, up to 16 times
+const DefaultProxyCooldown = 2 * time.Minute
+
+// maxProxyBackoff caps the doubling of cooldown: 1<<4 = 16 times
+const maxProxyBackoff = 4
+
+// ProxyProvider is consulted by NewBot/NewBotPool with WithProxyProvider,
+// a proxy is either a proxy line `[scheme://]host:port:username:password` or a proxy server `host:port`
+type ProxyProvider interface {
+	// Next returns a healthy proxy for domain, domain can be empty
+	Next(domain string) (string, error)
+	// ReportFailure benches the proxy for a cooldown, which may vary by reason
+	ReportFailure(proxy string, reason ProxyFailure)
+	// ReportSuccess resets the failure count of proxy
+	ReportSuccess(proxy string)
+}
+
+// LoadProxyFile reads proxies from file, one proxy per line,
+// empty lines and lines start with `#` are skipped
+func LoadProxyFile(path string) ([]string, error) {
+	f, err := os.Open(expandPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var proxies []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		proxies = append(proxies, line)
+	}
+	return proxies, scanner.Err()
+}
+
+// proxyHealth tracks failures of proxies, and benches failed ones for a cooldown
+type proxyHealth struct {
+	mu       sync.Mutex
+	proxies  []string
+	cooldown time.Duration
+	failures map[string]int
+	benched  map[string]time.Time
+
+	// now is replaceable in tests
+	now func() time.Time
+}
+
+func newProxyHealth(proxies []string) *proxyHealth {
+	return &proxyHealth{
+		proxies:  proxies,
+		cooldown: DefaultProxyCooldown,
+		failures: make(map[string]int),
+		benched:  make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// SetCooldown sets the cooldown of the first failure
+func (h *proxyHealth) SetCooldown(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cooldown = d
+}
+
+func (h *proxyHealth) ReportFailure(proxy string, reason ProxyFailure) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.failures[proxy] + reason.weight()
+	h.failures[proxy] = n
+	if n > maxProxyBackoff+1 {
+		n = maxProxyBackoff + 1
+	}
+	h.benched[proxy] = h.now().Add(h.cooldown << (n - 1))
+}
+
+func (h *proxyHealth) ReportSuccess(proxy string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.failures, proxy)
+	delete(h.benched, proxy)
+}
+
+// Benched returns whether proxy is in cooldown
+func (h *proxyHealth) Benched(proxy string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.isBenched(proxy)
+}
+
+func (h *proxyHealth) isBenched(proxy string) bool {
+	until, ok := h.benched[proxy]
+	if !ok {
+		return false
+	}
+	if h.now().Before(until) {
+		return true
+	}
+	delete(h.benched, proxy)
+	return false
+}
+
+// StaticProxyProvider always returns the first healthy proxy in list, the others are fallbacks
+type StaticProxyProvider struct {
+	*proxyHealth
+}
+
+func NewStaticProxyProvider(proxies ...string) *StaticProxyProvider {
+	return &StaticProxyProvider{proxyHealth: newProxyHealth(proxies)}
+}
+
+func (p *StaticProxyProvider) Next(_ string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, proxy := range p.proxies {
+		if !p.isBenched(proxy) {
+			return proxy, nil
+		}
+	}
+	return "", ErrorNoProxyAvailable
+}
+
+// RoundRobinProxyProvider returns healthy proxies in turn
+type RoundRobinProxyProvider struct {
+	*proxyHealth
+	next int
+}
+
+func NewRoundRobinProxyProvider(proxies ...string) *RoundRobinProxyProvider {
+	return &RoundRobinProxyProvider{proxyHealth: newProxyHealth(proxies)}
+}
+
+func (p *RoundRobinProxyProvider) Next(_ string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := 0; i < len(p.proxies); i++ {
+		proxy := p.proxies[p.next%len(p.proxies)]
+		p.next++
+		if !p.isBenched(proxy) {
+			return proxy, nil
+		}
+	}
+	return "", ErrorNoProxyAvailable
+}
+
+// StickyProxyProvider keeps the same proxy for a domain until it fails,
+// then picks a new one from the wrapped provider
+type StickyProxyProvider struct {
+	ProxyProvider
+
+	mu     sync.Mutex
+	sticky map[string]string
+}
+
+func NewStickyProxyProvider(provider ProxyProvider) *StickyProxyProvider {
+	return &StickyProxyProvider{
+		ProxyProvider: provider,
+		sticky:        make(map[string]string),
+	}
+}
+
+func (p *StickyProxyProvider) Next(domain string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if proxy, ok := p.sticky[domain]; ok {
+		return proxy, nil
+	}
+
+	proxy, err := p.ProxyProvider.Next(domain)
+	if err != nil {
+		return "", err
+	}
+	p.sticky[domain] = proxy
+	return proxy, nil
+}
+
+func (p *StickyProxyProvider) ReportFailure(proxy string, reason ProxyFailure) {
+	p.mu.Lock()
+	for domain, v := range p.sticky {
+		if v == proxy {
+			delete(p.sticky, domain)
+		}
+	}
+	p.mu.Unlock()
+
+	p.ProxyProvider.ReportFailure(proxy, reason)
+}
+
+// applyProxy sets proxy to cfg as ProxyLine or ProxyServer by its format
+func applyProxy(cfg *BotConfig, proxy string) {
+	if _, err := parseProxyLine(proxy); err == nil {
+		cfg.ProxyLine, cfg.ProxyServer = proxy, ""
+		return
+	}
+	cfg.ProxyLine, cfg.ProxyServer = "", proxy
+}
+
+// ClassifyProxyError returns the ProxyFailure of navigation error, and false if it's not caused by proxy,
+// ERR_CONNECTION_REFUSED is not a proxy failure, chrome reports the target site refusing with it
+func ClassifyProxyError(err error) (ProxyFailure, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	s := err.Error()
+	for _, reason := range []string{
+		"ERR_PROXY_CONNECTION_FAILED",
+		"ERR_TUNNEL_CONNECTION_FAILED",
+		"ERR_SOCKS_CONNECTION_FAILED",
+	} {
+		if strings.Contains(s, reason) {
+			return ProxyConnRefused, true
+		}
+	}
+
+	for _, reason := range []string{
+		"ERR_PROXY_AUTH_REQUESTED",
+		"ERR_PROXY_AUTH_UNSUPPORTED",
+		"ERR_INVALID_AUTH_CREDENTIALS",
+	} {
+		if strings.Contains(s, reason) {
+			return ProxyAuthRejected, true
+		}
+	}
+
+	return 0, false
+}
+
+// pickProxy consults the proxy provider of opt,
+// and returns opts appended with a copied BotConfig which uses the picked proxy
+func pickProxy(opt BotOpts, opts []BotOptFunc) (string, []BotOptFunc, error) {
+	if opt.proxyProvider == nil {
+		return "", opts, nil
+	}
+
+	proxy, err := opt.proxyProvider.Next(opt.proxyDomain)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cfg := *opt.BotCfg
+	applyProxy(&cfg, proxy)
+	return proxy, append(opts[:len(opts):len(opts)], WithBotConfig(&cfg)), nil
+}
+
+// ReportProxyFailure reports the proxy used by bot to its provider, e.g. when captcha is detected
+func (b *Bot) ReportProxyFailure(reason ProxyFailure) {
+	if b.proxyProvider == nil || b.Proxy == "" {
+		return
+	}
+	log.Warn().Str("proxy", b.Proxy).Str("reason", reason.String()).Msg("bench proxy")
+	b.proxyProvider.ReportFailure(b.Proxy, reason)
+}
+
+// reportProxyResult reports the proxy result by the error of navigation
+func (b *Bot) reportProxyResult(err error) {
+	if b.proxyProvider == nil || b.Proxy == "" {
+		return
+	}
+
+	if err == nil {
+		b.proxyProvider.ReportSuccess(b.Proxy)
+		return
+	}
+
+	if reason, ok := ClassifyProxyError(err); ok {
+		b.ReportProxyFailure(reason)
+	}
+}
@@ -0,0 +1,167 @@
+package xbot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProxyProviderSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func TestProxyProvider(t *testing.T) {
+	suite.Run(t, new(ProxyProviderSuite))
+}
+
+func (s *ProxyProviderSuite) SetupTest() {
+	s.now = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *ProxyProviderSuite) clock(h *proxyHealth) {
+	h.now = func() time.Time { return s.now }
+}
+
+func (s *ProxyProviderSuite) Test_01_Static() {
+	p := NewStaticProxyProvider("127.0.0.1:1001", "127.0.0.1:1002")
+	s.clock(p.proxyHealth)
+
+	for i := 0; i < 3; i++ {
+		v, err := p.Next("")
+		s.Nil(err)
+		s.Equal("127.0.0.1:1001", v)
+	}
+
+	p.ReportFailure("127.0.0.1:1001", ProxyConnRefused)
+	v, _ := p.Next("")
+	s.Equal("127.0.0.1:1002", v, "fallback when first is benched")
+
+	p.ReportFailure("127.0.0.1:1002", ProxyAuthRejected)
+	_, err := p.Next("")
+	s.ErrorIs(err, ErrorNoProxyAvailable)
+
+	s.now = s.now.Add(DefaultProxyCooldown)
+	v, _ = p.Next("")
+	s.Equal("127.0.0.1:1001", v, "back after cooldown")
+}
+
+func (s *ProxyProviderSuite) Test_02_RoundRobin() {
+	p := NewRoundRobinProxyProvider("a:1", "b:2", "c:3")
+	s.clock(p.proxyHealth)
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		v, _ := p.Next("")
+		got = append(got, v)
+	}
+	s.Equal([]string{"a:1", "b:2", "c:3", "a:1"}, got)
+
+	p.ReportFailure("b:2", ProxyCaptcha)
+	got = nil
+	for i := 0; i < 3; i++ {
+		v, _ := p.Next("")
+		got = append(got, v)
+	}
+	s.Equal([]string{"c:3", "a:1", "c:3"}, got)
+}
+
+func (s *ProxyProviderSuite) Test_03_Cooldown() {
+	p := NewRoundRobinProxyProvider("a:1")
+	s.clock(p.proxyHealth)
+	p.SetCooldown(time.Minute)
+
+	p.ReportFailure("a:1", ProxyConnRefused)
+	s.now = s.now.Add(time.Minute)
+	s.False(p.Benched("a:1"))
+
+	// consecutive failure doubles the cooldown
+	p.ReportFailure("a:1", ProxyConnRefused)
+	s.now = s.now.Add(time.Minute)
+	s.True(p.Benched("a:1"))
+	s.now = s.now.Add(time.Minute)
+	s.False(p.Benched("a:1"))
+
+	p.ReportFailure("a:1", ProxyConnRefused)
+	p.ReportSuccess("a:1")
+	s.False(p.Benched("a:1"))
+
+	// rejected auth is benched for the longest cooldown at once
+	p.ReportFailure("a:1", ProxyAuthRejected)
+	s.now = s.now.Add(15 * time.Minute)
+	s.True(p.Benched("a:1"))
+	s.now = s.now.Add(time.Minute)
+	s.False(p.Benched("a:1"))
+}
+
+func (s *ProxyProviderSuite) Test_04_Sticky() {
+	rr := NewRoundRobinProxyProvider("a:1", "b:2")
+	s.clock(rr.proxyHealth)
+	p := NewStickyProxyProvider(rr)
+
+	x1, _ := p.Next("x.com")
+	y1, _ := p.Next("y.com")
+	x2, _ := p.Next("x.com")
+	s.Equal("a:1", x1)
+	s.Equal("b:2", y1)
+	s.Equal(x1, x2, "same domain sticks to the same proxy")
+
+	p.ReportFailure(x1, ProxyCaptcha)
+	x3, _ := p.Next("x.com")
+	s.Equal("b:2", x3, "failed proxy is replaced")
+}
+
+func (s *ProxyProviderSuite) Test_05_LoadProxyFile() {
+	path := filepath.Join(s.T().TempDir(), "proxies.txt")
+	s.Nil(os.WriteFile(path, []byte("# comment\n127.0.0.1:8080:u:p\n\n  127.0.0.1:1080  \n"), 0o600))
+
+	proxies, err := LoadProxyFile(path)
+	s.Nil(err)
+	s.Equal([]string{"127.0.0.1:8080:u:p", "127.0.0.1:1080"}, proxies)
+}
+
+func (s *ProxyProviderSuite) Test_06_applyProxy() {
+	cfg := NewDefaultBotCfg()
+	applyProxy(cfg, "127.0.0.1:8080:u:p")
+	s.Equal("127.0.0.1:8080:u:p", cfg.ProxyLine)
+	s.Empty(cfg.ProxyServer)
+
+	applyProxy(cfg, "127.0.0.1:1080")
+	s.Empty(cfg.ProxyLine)
+	s.Equal("127.0.0.1:1080", cfg.ProxyServer)
+}
+
+func (s *ProxyProviderSuite) Test_07_ReportByNavigation() {
+	p := NewStaticProxyProvider("127.0.0.1:1")
+	s.clock(p.proxyHealth)
+	b := &Bot{Proxy: "127.0.0.1:1", proxyProvider: p}
+
+	b.reportProxyResult(&rod.ErrNavigation{Reason: "net::ERR_PROXY_CONNECTION_FAILED"})
+	s.True(p.Benched("127.0.0.1:1"))
+
+	b.reportProxyResult(nil)
+	s.False(p.Benched("127.0.0.1:1"))
+
+	b.reportProxyResult(&rod.ErrNavigation{Reason: "net::ERR_NAME_NOT_RESOLVED"})
+	s.False(p.Benched("127.0.0.1:1"), "non proxy errors are ignored")
+	b.reportProxyResult(&rod.ErrNavigation{Reason: "net::ERR_CONNECTION_REFUSED"})
+	s.False(p.Benched("127.0.0.1:1"), "target site refusing is not a proxy failure")
+
+	reason, ok := ClassifyProxyError(&rod.ErrNavigation{Reason: "net::ERR_INVALID_AUTH_CREDENTIALS"})
+	s.True(ok)
+	s.Equal(ProxyAuthRejected, reason)
+}
+
+func (s *ProxyProviderSuite) Test_08_pickProxy() {
+	opt := BotOpts{BotCfg: NewDefaultBotCfg(), proxyProvider: NewStaticProxyProvider("127.0.0.1:8080:u:p")}
+	proxy, opts, err := pickProxy(opt, nil)
+	s.Nil(err)
+	s.Equal("127.0.0.1:8080:u:p", proxy)
+
+	BindBotOpts(&opt, opts...)
+	s.Equal(proxy, opt.BotCfg.ProxyLine)
+}
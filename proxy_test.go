@@ -0,0 +1,50 @@
+package xbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProxySuite struct {
+	suite.Suite
+}
+
+func TestProxy(t *testing.T) {
+	suite.Run(t, new(ProxySuite))
+}
+
+func (s *ProxySuite) Test_01_parseProxyLine() {
+	tests := []struct {
+		line   string
+		server string
+		user   string
+		pass   string
+	}{
+		{line: "127.0.0.1:8080:user:pass", server: "http://127.0.0.1:8080", user: "user", pass: "pass"},
+		{line: "127.0.0.1:8080:user:pass:other", server: "http://127.0.0.1:8080", user: "user", pass: "pass"},
+		{line: "https://proxy.local:443:user:pass", server: "https://proxy.local:443", user: "user", pass: "pass"},
+		{line: "SOCKS5://127.0.0.1:1080:user:pass", server: "socks5://127.0.0.1:1080", user: "user", pass: "pass"},
+	}
+
+	for _, tt := range tests {
+		pl, err := parseProxyLine(tt.line)
+		s.Nil(err, tt.line)
+		s.Equal(tt.server, pl.Server(), tt.line)
+		s.Equal(tt.user, pl.Username, tt.line)
+		s.Equal(tt.pass, pl.Password, tt.line)
+	}
+}
+
+func (s *ProxySuite) Test_02_parseProxyLineErrors() {
+	for _, line := range []string{
+		"",
+		"127.0.0.1:8080",
+		":8080:user:pass",
+		"127.0.0.1:0:user:pass",
+		"ftp://127.0.0.1:21:user:pass",
+	} {
+		_, err := parseProxyLine(line)
+		s.NotNil(err, line)
+	}
+}
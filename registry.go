@@ -0,0 +1,207 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thoas/go-funk"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrorInvalidRegistry = errors.New("invalid page registry")
+
+// SelectorList is the alternatives of an element, tried in order as a SelectorChain,
+// in yaml it can be a single selector or a list of selectors
+type SelectorList []string
+
+func (l *SelectorList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = SelectorList{node.Value}
+		return nil
+	}
+
+	var ss []string
+	if err := node.Decode(&ss); err != nil {
+		return err
+	}
+	*l = ss
+	return nil
+}
+
+// PageObject describes a page: which urls it matches, its named elements and popovers
+type PageObject struct {
+	Name string `yaml:"name"`
+	// URL is a regex matched against the current url
+	URL      string                  `yaml:"url"`
+	Elements map[string]SelectorList `yaml:"elements"`
+	Popovers []string                `yaml:"popovers"`
+
+	urlRe *regexp.Regexp
+}
+
+// Match reports whether url belongs to the page
+func (p *PageObject) Match(url string) bool {
+	return p.urlRe != nil && p.urlRe.MatchString(url)
+}
+
+// Registry holds page objects, elements are referred by "page.element", e.g. "login.submit"
+//
+// yaml:
+//
+//	pages:
+//	  - name: login
+//	    url: 'example\.com/login'
+//	    popovers: [div.cookie-banner button.accept]
+//	    elements:
+//	      user: input#user
+//	      submit:
+//	        - button[type=submit]
+//	        - button@@@---@@@Sign in
+type Registry struct {
+	Pages []*PageObject `yaml:"pages"`
+
+	byName map[string]*PageObject
+}
+
+// NewRegistry creates a registry of pages
+func NewRegistry(pages ...*PageObject) (*Registry, error) {
+	r := &Registry{Pages: pages}
+	return r, r.init()
+}
+
+// LoadRegistry loads registry from yaml file
+func LoadRegistry(path string) (*Registry, error) {
+	raw, err := os.ReadFile(expandPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return ParseRegistry(raw)
+}
+
+// ParseRegistry parses registry from yaml
+func ParseRegistry(raw []byte) (*Registry, error) {
+	r := &Registry{}
+	if err := yaml.Unmarshal(raw, r); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidRegistry, err)
+	}
+	return r, r.init()
+}
+
+func (r *Registry) init() error {
+	r.byName = make(map[string]*PageObject, len(r.Pages))
+	for _, p := range r.Pages {
+		if p.Name == "" || strings.Contains(p.Name, ".") {
+			return fmt.Errorf("%w: page name %q should be non-empty and without dot", ErrorInvalidRegistry, p.Name)
+		}
+		if _, ok := r.byName[p.Name]; ok {
+			return fmt.Errorf("%w: duplicated page %q", ErrorInvalidRegistry, p.Name)
+		}
+
+		if p.URL != "" {
+			re, err := regexp.Compile(p.URL)
+			if err != nil {
+				return fmt.Errorf("%w: url of page %q: %v", ErrorInvalidRegistry, p.Name, err)
+			}
+			p.urlRe = re
+		}
+
+		for name, alts := range p.Elements {
+			if len(alts) == 0 {
+				return fmt.Errorf("%w: element %s.%s has no selector", ErrorInvalidRegistry, p.Name, name)
+			}
+		}
+
+		r.byName[p.Name] = p
+	}
+	return nil
+}
+
+// Page returns the page of name or nil
+func (r *Registry) Page(name string) *PageObject {
+	return r.byName[name]
+}
+
+// Match returns the first page which matches url or nil
+func (r *Registry) Match(url string) *PageObject {
+	for _, p := range r.Pages {
+		if p.Match(url) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Lookup returns the selector alternatives of name like "login.submit"
+func (r *Registry) Lookup(name string) (SelectorList, bool) {
+	i := strings.Index(name, ".")
+	if i <= 0 {
+		return nil, false
+	}
+
+	p := r.byName[name[:i]]
+	if p == nil {
+		return nil, false
+	}
+
+	alts, ok := p.Elements[name[i+1:]]
+	return alts, ok
+}
+
+// UseRegistry binds registry to bot, so bot methods accept element names like "login.submit",
+// and popovers of the page matching current url are bound automatically
+func (b *Bot) UseRegistry(r *Registry) {
+	b.registry = r
+}
+
+// lookupName returns the chain of alternatives named by v, if v is an element name of bot's registry
+func (b *Bot) lookupName(v interface{}) (SelectorChain, bool) {
+	name, ok := v.(string)
+	if !ok || b.registry == nil {
+		return SelectorChain{}, false
+	}
+
+	alts, ok := b.registry.Lookup(name)
+	return SelectorChain{Name: name, Selectors: alts}, ok
+}
+
+// syncPopovers binds popovers of the registry page which matches current url
+func (b *Bot) syncPopovers() {
+	if b.registry == nil || b.Pg == nil {
+		b.pagePopovers = nil
+		return
+	}
+
+	info, err := b.Pg.Timeout(b.NapToSec).Info()
+	if err != nil {
+		log.Debug().Err(err).Msg("cannot get current url")
+		return
+	}
+	b.setPagePopovers(info.URL)
+}
+
+// setPagePopovers binds popovers of the registry page which matches url, none if no page matches
+func (b *Bot) setPagePopovers(url string) {
+	b.pagePopovers = nil
+	if page := b.registry.Match(url); page != nil {
+		b.pagePopovers = page.Popovers
+	}
+}
+
+// allPopovers returns popovers bound by BindPopovers, followed by the ones of registry page not bound yet
+func (b *Bot) allPopovers() []string {
+	if len(b.pagePopovers) == 0 {
+		return b.popovers
+	}
+
+	all := append([]string{}, b.popovers...)
+	for _, sel := range b.pagePopovers {
+		if !funk.ContainsString(all, sel) {
+			all = append(all, sel)
+		}
+	}
+	return all
+}
@@ -0,0 +1,108 @@
+package xbot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistrySuite struct {
+	suite.Suite
+}
+
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(RegistrySuite))
+}
+
+const registryYAML = `
+pages:
+  - name: login
+    url: 'example\.com/login'
+    popovers: [div.cookie button.accept]
+    elements:
+      user: input#user
+      submit:
+        - button[type=submit]
+        - button@@@---@@@Sign in
+  - name: search
+    url: 'example\.com/(search|s)\?'
+    elements:
+      query: input[name=q]
+`
+
+func (s *RegistrySuite) Test_01_Parse() {
+	r, err := ParseRegistry([]byte(registryYAML))
+	s.Require().NoError(err)
+
+	alts, ok := r.Lookup("login.submit")
+	s.True(ok)
+	s.Equal(SelectorList{"button[type=submit]", "button@@@---@@@Sign in"}, alts)
+
+	alts, ok = r.Lookup("login.user")
+	s.True(ok)
+	s.Equal(SelectorList{"input#user"}, alts)
+
+	for _, name := range []string{"login.none", "none.user", "div.user", "login", ".user"} {
+		_, ok = r.Lookup(name)
+		s.False(ok, name)
+	}
+
+	s.Equal("login", r.Match("https://example.com/login?next=/").Name)
+	s.Equal("search", r.Match("https://example.com/s?q=go").Name)
+	s.Nil(r.Match("https://example.com/"))
+	s.Equal([]string{"div.cookie button.accept"}, r.Page("login").Popovers)
+}
+
+func (s *RegistrySuite) Test_02_Invalid() {
+	tests := []string{
+		"pages: [{name: a}, {name: a}]",
+		"pages: [{name: a.b}]",
+		"pages: [{url: x}]",
+		"pages: [{name: a, url: '('}]",
+		"pages: [{name: a, elements: {x: []}}]",
+		"pages: {}",
+	}
+	for _, raw := range tests {
+		_, err := ParseRegistry([]byte(raw))
+		s.True(errors.Is(err, ErrorInvalidRegistry), raw)
+	}
+}
+
+func (s *RegistrySuite) Test_03_LookupName() {
+	r, err := NewRegistry(&PageObject{Name: "home", Elements: map[string]SelectorList{"logo": {"img.logo"}}})
+	s.Require().NoError(err)
+
+	b := &Bot{}
+	_, ok := b.lookupName("home.logo")
+	s.False(ok, "no registry bound")
+
+	b.UseRegistry(r)
+	chain, ok := b.lookupName("home.logo")
+	s.True(ok)
+	s.Equal(SelectorChain{Name: "home.logo", Selectors: []string{"img.logo"}}, chain)
+
+	_, ok = b.lookupName(Sel("home.logo"))
+	s.False(ok, "Selector is never a name")
+}
+
+func (s *RegistrySuite) Test_04_Popovers() {
+	r, err := ParseRegistry([]byte(registryYAML))
+	s.Require().NoError(err)
+
+	b := &Bot{}
+	b.UseRegistry(r)
+	b.BindPopovers([]string{"div.modal", "div.cookie button.accept"})
+	s.Equal([]string{"div.modal", "div.cookie button.accept"}, b.allPopovers())
+
+	b.BindPopovers([]string{"div.modal"})
+	b.setPagePopovers("https://example.com/login")
+	s.Equal([]string{"div.modal", "div.cookie button.accept"}, b.allPopovers(), "merged with the page's")
+
+	b.setPagePopovers("https://example.com/about")
+	s.Equal([]string{"div.modal"}, b.allPopovers(), "bound ones are kept after leaving the page")
+
+	b.BindPopovers([]string{"div.cookie button.accept"})
+	b.setPagePopovers("https://example.com/login")
+	s.Equal([]string{"div.cookie button.accept"}, b.allPopovers(), "no duplicates")
+}
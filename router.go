@@ -0,0 +1,538 @@
+package xbot
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rs/zerolog/log"
+	"github.com/thoas/go-funk"
+)
+
+// RouteAction is what a RouteRule does to the matched request
+type RouteAction int
+
+const (
+	// ActionContinue continues the request, with headers/body modified if set
+	ActionContinue RouteAction = iota
+	// ActionBlock fails the request as blocked by client
+	ActionBlock
+	// ActionFulfill responds with the status, headers and body (or fixture file) of rule
+	ActionFulfill
+	// ActionRewrite loads the real response, and responds with it after rewritten
+	ActionRewrite
+	// ActionDelay sleeps, then goes on matching the following rules
+	ActionDelay
+	// ActionHandle calls the handler of rule, which works like a handler of HijackRequests,
+	// set ctx.Skip to go on matching the following rules
+	ActionHandle
+)
+
+// RouteRule matches requests by url pattern, resource types and methods, and acts on the matched
+type RouteRule struct {
+	Name string
+	// URL is a glob like "*/api/*" or a regex with RegexPrefix, empty matches all
+	URL string
+	// Types and Methods match all if empty
+	Types   []proto.NetworkResourceType
+	Methods []string
+	Action  RouteAction
+
+	// Headers are set to request (ActionContinue) or response (ActionFulfill), empty value removes the header
+	Headers map[string]string
+	// Body replaces the request body (ActionContinue) or is the response body (ActionFulfill)
+	Body []byte
+
+	// Status of ActionFulfill, 200 by default
+	Status int
+	// File is the fixture of ActionFulfill, which is read on each request, Content-Type is guessed by extension
+	File string
+
+	Delay   time.Duration
+	Rewrite func(res *rod.HijackResponse)
+	Handler func(ctx *rod.Hijack)
+
+	re *regexp.Regexp
+}
+
+// RouteBlock blocks requests of url
+func RouteBlock(url string) *RouteRule {
+	return &RouteRule{URL: url, Action: ActionBlock}
+}
+
+// RouteContinue continues requests of url, use SetHeader/SetBody to modify them
+func RouteContinue(url string) *RouteRule {
+	return &RouteRule{URL: url, Action: ActionContinue}
+}
+
+// RouteFulfill responds requests of url with status and body
+func RouteFulfill(url string, status int, body []byte) *RouteRule {
+	return &RouteRule{URL: url, Action: ActionFulfill, Status: status, Body: body}
+}
+
+// RouteFulfillFile responds requests of url with the fixture file
+func RouteFulfillFile(url string, path string) *RouteRule {
+	return &RouteRule{URL: url, Action: ActionFulfill, File: path}
+}
+
+// RouteRewrite responds requests of url with the real response rewritten by fn
+func RouteRewrite(url string, fn func(res *rod.HijackResponse)) *RouteRule {
+	return &RouteRule{URL: url, Action: ActionRewrite, Rewrite: fn}
+}
+
+// RouteDelay delays requests of url by d
+func RouteDelay(url string, d time.Duration) *RouteRule {
+	return &RouteRule{URL: url, Action: ActionDelay, Delay: d}
+}
+
+// RouteHandle handles requests of url by fn
+func RouteHandle(url string, fn func(ctx *rod.Hijack)) *RouteRule {
+	return &RouteRule{URL: url, Action: ActionHandle, Handler: fn}
+}
+
+// Named sets the name, which is used by Router.Remove
+func (r *RouteRule) Named(name string) *RouteRule {
+	r.Name = name
+	return r
+}
+
+// OfType limits the rule to resource types
+func (r *RouteRule) OfType(types ...proto.NetworkResourceType) *RouteRule {
+	r.Types = append(r.Types, types...)
+	return r
+}
+
+// OfMethod limits the rule to methods
+func (r *RouteRule) OfMethod(methods ...string) *RouteRule {
+	r.Methods = append(r.Methods, methods...)
+	return r
+}
+
+func (r *RouteRule) SetHeader(name, value string) *RouteRule {
+	if r.Headers == nil {
+		r.Headers = make(map[string]string)
+	}
+	r.Headers[name] = value
+	return r
+}
+
+func (r *RouteRule) SetBody(body []byte) *RouteRule {
+	r.Body = body
+	return r
+}
+
+func (r *RouteRule) compile() (err error) {
+	r.re, err = compileURLPattern(r.URL)
+	return err
+}
+
+func (r *RouteRule) match(url, method string, typ proto.NetworkResourceType) bool {
+	if len(r.Types) != 0 && !funk.Contains(r.Types, typ) {
+		return false
+	}
+	if len(r.Methods) != 0 && !funk.ContainsString(r.Methods, strings.ToUpper(method)) {
+		return false
+	}
+	return r.re.MatchString(url)
+}
+
+// Router routes requests of a browser by ordered rules, the first matched rule acts,
+// except ActionDelay and skipped ActionHandle which go on matching.
+// Unmatched requests are continued.
+//
+// All rules share one HijackRequests router, so they don't compete with each other.
+// The router owns the Fetch domain of its browser or page, and answers proxy authentication too,
+// since another Fetch.enable of the same session would override it, see routerOf
+type Router struct {
+	mu    sync.RWMutex
+	rules []*RouteRule
+
+	// username and password answer the auth challenges of proxy,
+	// answered are the requests answered, so a rejected one is cancelled instead of retried forever
+	username string
+	password string
+	answered map[proto.FetchRequestID]bool
+
+	hijack *rod.HijackRouter
+	cancel context.CancelFunc
+	// forget removes the router from routers once stopped
+	forget func()
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Add appends rules, it can be called after Start
+func (r *Router) Add(rules ...*RouteRule) error {
+	for _, rule := range rules {
+		if err := rule.compile(); err != nil {
+			return err
+		}
+		for i, m := range rule.Methods {
+			rule.Methods[i] = strings.ToUpper(m)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, rules...)
+	return nil
+}
+
+func (r *Router) MustAdd(rules ...*RouteRule) {
+	if err := r.Add(rules...); err != nil {
+		panic(err)
+	}
+}
+
+// Remove removes rules of name
+func (r *Router) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.rules[:0:0]
+	for _, rule := range r.rules {
+		if rule.Name != name {
+			kept = append(kept, rule)
+		}
+	}
+	r.rules = kept
+}
+
+// Clear removes all rules
+func (r *Router) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = nil
+}
+
+// Rules returns the rules in order
+func (r *Router) Rules() []*RouteRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*RouteRule(nil), r.rules...)
+}
+
+// Start hijacks requests of brw, a browser should have only one router,
+// use Bot.Router to get the shared one
+func (r *Router) Start(brw *rod.Browser) {
+	r.start(brw, brw.HijackRequests())
+}
+
+// start hijacks requests of target, and enables Fetch with auth requests handled
+func (r *Router) start(target fetchTarget, hijack *rod.HijackRouter) {
+	r.hijack = hijack
+	r.hijack.MustAdd("*", r.handle)
+
+	// rod enables Fetch without auth requests, enable it again with the same pattern,
+	// the router never adds patterns to rod's, so it's not overridden later
+	err := proto.FetchEnable{Patterns: []*proto.FetchRequestPattern{{URLPattern: "*"}}, HandleAuthRequests: true}.Call(target)
+	if err != nil {
+		log.Warn().Err(err).Msg("enable fetch with auth requests")
+	}
+
+	ctx, cancel := context.WithCancel(target.GetContext())
+	r.cancel = cancel
+
+	var wait func()
+	switch t := target.(type) {
+	case *rod.Browser:
+		wait = t.Context(ctx).EachEvent(func(e *proto.FetchAuthRequired, id proto.TargetSessionID) {
+			// events of page sessions are answered by their own routers
+			if id == "" {
+				r.answerAuth(target, e)
+			}
+		})
+	case *rod.Page:
+		wait = t.Context(ctx).EachEvent(func(e *proto.FetchAuthRequired) {
+			r.answerAuth(target, e)
+		})
+	}
+
+	go r.hijack.Run()
+	go func() {
+		wait()
+		if r.forget != nil {
+			r.forget()
+		}
+	}()
+}
+
+// setProxyAuth answers auth challenges of proxy with username and password
+func (r *Router) setProxyAuth(username, password string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.username, r.password = username, password
+	r.answered = make(map[proto.FetchRequestID]bool)
+}
+
+// authResponse returns the response to challenge of request id
+func (r *Router) authResponse(id proto.FetchRequestID, challenge *proto.FetchAuthChallenge) *proto.FetchAuthChallengeResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case challenge.Source != proto.FetchAuthChallengeSourceProxy || r.username == "":
+		return &proto.FetchAuthChallengeResponse{Response: proto.FetchAuthChallengeResponseResponseDefault}
+	case r.answered[id]:
+		log.Error().Str("origin", challenge.Origin).Msg("proxy rejected the credentials")
+		return &proto.FetchAuthChallengeResponse{Response: proto.FetchAuthChallengeResponseResponseCancelAuth}
+	}
+
+	if len(r.answered) >= maxAnsweredAuth {
+		r.answered = make(map[proto.FetchRequestID]bool)
+	}
+	r.answered[id] = true
+
+	return &proto.FetchAuthChallengeResponse{
+		Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+		Username: r.username,
+		Password: r.password,
+	}
+}
+
+func (r *Router) answerAuth(target fetchTarget, e *proto.FetchAuthRequired) {
+	resp := r.authResponse(e.RequestID, e.AuthChallenge)
+	err := proto.FetchContinueWithAuth{RequestID: e.RequestID, AuthChallengeResponse: resp}.Call(target)
+	if err != nil {
+		log.Debug().Err(err).Msg("continue with auth")
+	}
+}
+
+// Stop stops hijacking and answering proxy authentication
+func (r *Router) Stop() error {
+	if r.hijack == nil {
+		return nil
+	}
+	r.cancel()
+	if r.forget != nil {
+		r.forget()
+	}
+	return r.hijack.Stop()
+}
+
+// plan returns the rules to apply in order: delays and handlers, then the final rule if any
+func (r *Router) plan(url, method string, typ proto.NetworkResourceType) []*RouteRule {
+	var rules []*RouteRule
+	for _, rule := range r.Rules() {
+		if !rule.match(url, method, typ) {
+			continue
+		}
+		rules = append(rules, rule)
+		if rule.Action != ActionDelay && rule.Action != ActionHandle {
+			break
+		}
+	}
+	return rules
+}
+
+func (r *Router) handle(ctx *rod.Hijack) {
+	url, method, typ := ctx.Request.URL().String(), ctx.Request.Method(), ctx.Request.Type()
+
+	for _, rule := range r.plan(url, method, typ) {
+		switch rule.Action {
+		case ActionDelay:
+			select {
+			case <-time.After(rule.Delay):
+			case <-ctx.Request.Req().Context().Done():
+				return
+			}
+			continue
+		case ActionHandle:
+			rule.Handler(ctx)
+			if ctx.Skip {
+				ctx.Skip = false
+				continue
+			}
+		case ActionBlock:
+			ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+		case ActionFulfill:
+			fulfill(ctx, rule)
+		case ActionRewrite:
+			if err := ctx.LoadResponse(http.DefaultClient, true); err != nil {
+				log.Warn().Err(err).Str("url", url).Msg("load response to rewrite")
+				ctx.Response.Fail(proto.NetworkErrorReasonFailed)
+				return
+			}
+			rule.Rewrite(ctx.Response)
+		case ActionContinue:
+			ctx.ContinueRequest(continueRequest(ctx.Request.Headers(), rule))
+		}
+		return
+	}
+
+	ctx.ContinueRequest(&proto.FetchContinueRequest{})
+}
+
+// continueRequest returns the request with headers and body of rule applied
+func continueRequest(headers proto.NetworkHeaders, rule *RouteRule) *proto.FetchContinueRequest {
+	req := &proto.FetchContinueRequest{PostData: rule.Body}
+	if len(rule.Headers) == 0 {
+		return req
+	}
+
+	merged := make(map[string]string, len(headers)+len(rule.Headers))
+	names := make(map[string]string)
+	for k, v := range headers {
+		merged[k], names[strings.ToLower(k)] = v.Str(), k
+	}
+	for k, v := range rule.Headers {
+		if orig, ok := names[strings.ToLower(k)]; ok {
+			delete(merged, orig)
+		}
+		if v != "" {
+			merged[k] = v
+		}
+	}
+
+	keys := funk.Keys(merged).([]string)
+	sort.Strings(keys)
+	for _, k := range keys {
+		req.Headers = append(req.Headers, &proto.FetchHeaderEntry{Name: k, Value: merged[k]})
+	}
+	return req
+}
+
+// fixture returns the body and content type to fulfill by rule
+func fixture(rule *RouteRule) (body []byte, contentType string, err error) {
+	body, contentType = rule.Body, rule.Headers["Content-Type"]
+	if rule.File != "" {
+		if body, err = os.ReadFile(expandPath(rule.File)); err != nil {
+			return nil, "", err
+		}
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(rule.File))
+		}
+	}
+	return body, contentType, nil
+}
+
+func fulfill(ctx *rod.Hijack, rule *RouteRule) {
+	body, contentType, err := fixture(rule)
+	if err != nil {
+		log.Warn().Err(err).Str("file", rule.File).Msg("read fixture")
+		ctx.Response.Fail(proto.NetworkErrorReasonFailed)
+		return
+	}
+
+	ctx.Response.Payload().ResponseCode = rule.Status
+	if rule.Status == 0 {
+		ctx.Response.Payload().ResponseCode = http.StatusOK
+	}
+	if contentType != "" {
+		ctx.Response.SetHeader("Content-Type", contentType)
+	}
+	for k, v := range rule.Headers {
+		if v != "" && !strings.EqualFold(k, "Content-Type") {
+			ctx.Response.SetHeader(k, v)
+		}
+	}
+	ctx.Response.SetBody(body)
+}
+
+// Router returns the router of bot's browser, it's started on first call,
+// and shared by all bots of the browser, including the ones of its incognito contexts
+//
+//	b.Router().MustAdd(
+//		RouteBlock("*.woff2"),
+//		RouteFulfillFile("*/api/items*", "testdata/items.json").OfMethod("GET"),
+//		RouteDelay("*/api/*", time.Second),
+//	)
+func (b *Bot) Router() *Router {
+	return b.mustRouterOf(b.Brw)
+}
+
+// AddRoutes appends rules to bot's router
+func (b *Bot) AddRoutes(rules ...*RouteRule) error {
+	r, err := routerOf(b.Brw)
+	if err != nil {
+		return err
+	}
+	return r.Add(rules...)
+}
+
+// mustRouterOf returns the router of brw, the helpers like DisableImages accept any browser
+func (b *Bot) mustRouterOf(brw *rod.Browser) *Router {
+	r, err := routerOf(brw)
+	b.PanicIfErr(err)
+	return r
+}
+
+// routers are the started routers by the target id of their owners, a page or a browser process,
+// they're shared since copies of rod.Browser (incognito, with context...) are the same Fetch session
+var (
+	routersMu sync.Mutex
+	routers   = make(map[proto.TargetTargetID]*Router)
+)
+
+// routerOf returns the router of target (a browser or page), it's started on first call
+func routerOf(target fetchTarget) (*Router, error) {
+	id, err := fetchOwner(target)
+	if err != nil {
+		return nil, err
+	}
+
+	routersMu.Lock()
+	defer routersMu.Unlock()
+
+	if r, ok := routers[id]; ok {
+		return r, nil
+	}
+
+	r := NewRouter()
+	r.forget = func() {
+		routersMu.Lock()
+		defer routersMu.Unlock()
+		if routers[id] == r {
+			delete(routers, id)
+		}
+	}
+
+	// the router is shared, so it outlives the ctx of the bot which starts it,
+	// it stops once the browser disconnects or the page is closed
+	switch t := target.(type) {
+	case *rod.Browser:
+		t = t.Context(context.Background())
+		r.start(t, t.HijackRequests())
+	case *rod.Page:
+		t = t.Context(context.Background())
+		r.start(t, t.HijackRequests())
+		go t.Browser().EachEvent(func(e *proto.TargetTargetDestroyed) bool {
+			if e.TargetID == t.TargetID {
+				_ = r.Stop()
+				return true
+			}
+			return false
+		})()
+	}
+
+	routers[id] = r
+	return r, nil
+}
+
+// fetchOwner returns the target id of page, or of the browser process,
+// Fetch of a browser session is shared by all its copies
+func fetchOwner(target fetchTarget) (proto.TargetTargetID, error) {
+	switch t := target.(type) {
+	case *rod.Page:
+		return t.TargetID, nil
+	case *rod.Browser:
+		res, err := proto.TargetGetTargetInfo{}.Call(t)
+		if err != nil {
+			return "", err
+		}
+		return res.TargetInfo.TargetID, nil
+	}
+	return "", fmt.Errorf("unsupported fetch target %T", target)
+}
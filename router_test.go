@@ -0,0 +1,155 @@
+package xbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/suite"
+	"github.com/ysmood/gson"
+)
+
+type RouterSuite struct {
+	suite.Suite
+}
+
+func TestRouter(t *testing.T) {
+	suite.Run(t, new(RouterSuite))
+}
+
+func actions(rules []*RouteRule) (out []RouteAction) {
+	for _, r := range rules {
+		out = append(out, r.Action)
+	}
+	return out
+}
+
+func (s *RouterSuite) Test_01_Plan() {
+	r := NewRouter()
+	r.MustAdd(
+		RouteDelay("*/api/*", time.Second),
+		RouteBlock("*").OfType(proto.NetworkResourceTypeImage).Named("images"),
+		RouteFulfill("*/api/items*", 200, []byte(`[]`)).OfMethod("get"),
+		RouteContinue(`regex=/api/`).SetHeader("X-Test", "1"),
+	)
+
+	tests := []struct {
+		url    string
+		method string
+		typ    proto.NetworkResourceType
+		want   []RouteAction
+	}{
+		{"https://a.com/logo.png", "GET", proto.NetworkResourceTypeImage, []RouteAction{ActionBlock}},
+		{"https://a.com/api/items?p=1", "GET", proto.NetworkResourceTypeXHR, []RouteAction{ActionDelay, ActionFulfill}},
+		{"https://a.com/api/items", "POST", proto.NetworkResourceTypeFetch, []RouteAction{ActionDelay, ActionContinue}},
+		{"https://a.com/index.html", "GET", proto.NetworkResourceTypeDocument, nil},
+	}
+	for _, tt := range tests {
+		s.Equal(tt.want, actions(r.plan(tt.url, tt.method, tt.typ)), tt.url)
+	}
+
+	r.Remove("images")
+	s.Len(r.Rules(), 3)
+	s.Nil(r.plan("https://a.com/logo.png", "GET", proto.NetworkResourceTypeImage))
+
+	s.Error(r.Add(RouteBlock("regex=(")))
+	s.Len(r.Rules(), 3)
+
+	r.Clear()
+	s.Empty(r.Rules())
+}
+
+func (s *RouterSuite) Test_02_ContinueRequest() {
+	headers := proto.NetworkHeaders{
+		"Accept":     gson.New("*/*"),
+		"User-Agent": gson.New("bot"),
+		"Cookie":     gson.New("a=1"),
+	}
+
+	req := continueRequest(headers, RouteContinue("*"))
+	s.Nil(req.Headers)
+
+	rule := RouteContinue("*").SetHeader("user-agent", "xbot").SetHeader("cookie", "").SetBody([]byte("q=1"))
+	req = continueRequest(headers, rule)
+	s.Equal([]*proto.FetchHeaderEntry{
+		{Name: "Accept", Value: "*/*"},
+		{Name: "user-agent", Value: "xbot"},
+	}, req.Headers)
+	s.Equal([]byte("q=1"), req.PostData)
+}
+
+func (s *RouterSuite) Test_03_Fixture() {
+	path := filepath.Join(s.T().TempDir(), "items.json")
+	s.Require().NoError(os.WriteFile(path, []byte(`[1]`), 0o600))
+
+	body, ct, err := fixture(RouteFulfillFile("*", path))
+	s.NoError(err)
+	s.Equal([]byte(`[1]`), body)
+	s.Equal("application/json", ct)
+
+	_, ct, err = fixture(RouteFulfillFile("*", path).SetHeader("Content-Type", "text/plain"))
+	s.NoError(err)
+	s.Equal("text/plain", ct)
+
+	_, _, err = fixture(RouteFulfillFile("*", path+".missing"))
+	s.Error(err)
+
+	body, _, err = fixture(RouteFulfill("*", 404, []byte("nope")))
+	s.NoError(err)
+	s.Equal([]byte("nope"), body)
+}
+
+func (s *RouterSuite) Test_04_AuthResponse() {
+	proxy := &proto.FetchAuthChallenge{Source: proto.FetchAuthChallengeSourceProxy, Origin: "http://proxy"}
+	server := &proto.FetchAuthChallenge{Source: proto.FetchAuthChallengeSourceServer, Origin: "http://a.com"}
+
+	r := NewRouter()
+	s.Equal(proto.FetchAuthChallengeResponseResponseDefault, r.authResponse("1", proxy).Response, "no credentials")
+
+	r.setProxyAuth("u", "p")
+	s.Equal(&proto.FetchAuthChallengeResponse{
+		Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+		Username: "u",
+		Password: "p",
+	}, r.authResponse("1", proxy))
+	s.Equal(proto.FetchAuthChallengeResponseResponseCancelAuth, r.authResponse("1", proxy).Response, "rejected")
+	s.Equal(proto.FetchAuthChallengeResponseResponseDefault, r.authResponse("2", server).Response)
+}
+
+// Test_05_RoutesWithProxyAuth checks routes and proxy auth share the Fetch domain of one browser
+func (s *RouterSuite) Test_05_RoutesWithProxyAuth() {
+	// a local proxy stand-in, which asks for credentials before answering
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") == "" {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="xbot"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		_, _ = w.Write([]byte("<html><body><div id=proxied>proxied</div></body></html>"))
+	}))
+	defer stub.Close()
+
+	cfg := NewDefaultBotCfg()
+	cfg.UserAgent, cfg.Headless = UA, true
+	cfg.ProxyLine = strings.TrimPrefix(stub.URL, "http://") + ":u:p"
+
+	b, err := NewBotE(WithBotConfig(cfg))
+	if err != nil {
+		s.T().Skipf("browser not available: %v", err)
+	}
+	defer b.Close()
+
+	b.Router().MustAdd(RouteFulfill("*/routed", 200, []byte("<html><body><div id=routed>routed</div></body></html>")))
+	s.Same(b.Router(), b.Router(), "one router per browser")
+
+	s.Require().NoError(b.GetPageE("http://xbot.test/routed"))
+	s.NotNil(b.GetElem("#routed", BotTimeout(5)))
+
+	s.Require().NoError(b.GetPageE("http://xbot.test/other"))
+	s.NotNil(b.GetElem("#proxied", BotTimeout(5)), "unmatched requests are authenticated by the same router")
+}
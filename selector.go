@@ -0,0 +1,305 @@
+package xbot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// exactTextFlag is the middle part of "css@@@---@@@text", which means text must match exactly
+const exactTextFlag = "---"
+
+// xpathPrefix marks the string form of selector is an XPath expression
+const xpathPrefix = "xpath="
+
+// ShadowPierce separates css of shadow host and css within its open shadow root,
+// e.g. "my-form >>> my-input >>> input" for nested shadow roots
+const ShadowPierce = ">>>"
+
+// Selector describes how to find elements:
+//   - one of CSS / XPath is required, XPath wins if both are set
+//   - Text / Regex filter elements by their text, Regex wins if both are set
+//   - Nth picks one of the matched elements, python style index is supported (-1 is the last)
+//   - Root limits the search within an element
+type Selector struct {
+	// CSS supports ShadowPierce to descend into open shadow roots
+	CSS string
+	// XPath is evaluated against Root if set, so use relative path like ".//a" to search within Root
+	XPath string
+
+	// Text matched elements' text must contain Text, or equal to it if Exact
+	Text  string
+	Exact bool
+	// Regex is a javascript regex source without slashes, e.g. `^\d+$`
+	Regex string
+
+	CaseInsensitive bool
+	// regexFlags are the flags of Regex other than "i", parsed from "/source/flags"
+	regexFlags string
+
+	// Nth is nil if not specified, use At to set it
+	Nth  *int
+	Root *rod.Element
+
+	// frame is the iframe to search in, nil for the bot's page
+	frame *rod.Page
+}
+
+// Sel creates a Selector of css
+func Sel(css string) Selector {
+	return Selector{CSS: css}
+}
+
+// XPath creates a Selector of XPath expression
+func XPath(expr string) Selector {
+	return Selector{XPath: expr}
+}
+
+// HasText returns a copy of s which matches elements whose text contains txt
+func (s Selector) HasText(txt string) Selector {
+	s.Text, s.Exact, s.Regex = txt, false, ""
+	return s
+}
+
+// ExactText returns a copy of s which matches elements whose text equals to txt
+func (s Selector) ExactText(txt string) Selector {
+	s.Text, s.Exact, s.Regex = txt, true, ""
+	return s
+}
+
+// MatchRegex returns a copy of s which matches elements whose text matches regex
+func (s Selector) MatchRegex(regex string) Selector {
+	s.Regex = regex
+	return s
+}
+
+// IgnoreCase returns a copy of s which matches text case-insensitively
+func (s Selector) IgnoreCase() Selector {
+	s.CaseInsensitive = true
+	return s
+}
+
+// At returns a copy of s which picks the i-th matched element
+func (s Selector) At(i int) Selector {
+	s.Nth = &i
+	return s
+}
+
+// Within returns a copy of s which searches within root
+func (s Selector) Within(root *rod.Element) Selector {
+	s.Root = root
+	return s
+}
+
+// ParseSelector parses the string form of selector:
+//   - "css" plain css selector
+//   - "css@@@regex" elements whose text matches regex, "/regex/flags" is supported like rod's ElementR, e.g. "a@@@/next/i"
+//   - "css@@@---@@@text" elements whose text equals to text
+//   - "host >>> css" elements within the open shadow root of host, see ShadowPierce
+//
+// XPath can be used in place of css, it starts with "/", "./", "(" or "xpath=",
+// e.g. "//table//td[2]", "xpath=.//a@@@Next"
+func ParseSelector(raw string) Selector {
+	ss := strings.Split(raw, SEP)
+	s := Selector{CSS: ss[0]}
+	if isXPath(ss[0]) {
+		s = XPath(strings.TrimPrefix(ss[0], xpathPrefix))
+	}
+	if len(ss) == 1 {
+		return s
+	}
+
+	if len(ss) == 3 && ss[1] == exactTextFlag {
+		return s.ExactText(ss[2])
+	}
+
+	source, flags := parseRegex(strings.Join(ss[1:], SEP))
+	s = s.MatchRegex(source)
+	s.regexFlags = strings.ReplaceAll(flags, "i", "")
+	s.CaseInsensitive = strings.Contains(flags, "i")
+	return s
+}
+
+// slashedRegex is "/source/flags", same as rod's ElementR
+var slashedRegex = regexp.MustCompile(`^/(.+)/([a-z]*)$`)
+
+// parseRegex returns source and flags of "/source/flags",
+// raw is the source as is if it's not slashed or its flags are invalid
+func parseRegex(raw string) (source, flags string) {
+	m := slashedRegex.FindStringSubmatch(raw)
+	if m == nil {
+		return raw, ""
+	}
+
+	for i, f := range m[2] {
+		// "g" and "y" are dropped, since they make RegExp.test stateful
+		if !strings.ContainsRune("gimsuy", f) || strings.ContainsRune(m[2][i+1:], f) {
+			return raw, ""
+		}
+	}
+	return m[1], strings.NewReplacer("g", "", "y", "").Replace(m[2])
+}
+
+// String returns the string form of s, Nth and Root are not included
+func (s Selector) String() string {
+	base := s.CSS
+	if s.XPath != "" {
+		base = s.XPath
+		if !isXPath(base) {
+			base = xpathPrefix + base
+		}
+	}
+
+	switch {
+	case s.Regex != "" && (s.CaseInsensitive || s.regexFlags != ""):
+		_, flags := s.textRegex()
+		return base + SEP + "/" + s.Regex + "/" + flags
+	case s.Regex != "":
+		return base + SEP + s.Regex
+	case s.Exact:
+		return base + SEP + exactTextFlag + SEP + s.Text
+	case s.Text != "":
+		return base + SEP + regexp.QuoteMeta(s.Text)
+	}
+	return base
+}
+
+// IsEmpty reports whether s has neither css nor xpath
+func (s Selector) IsEmpty() bool {
+	return s.CSS == "" && s.XPath == ""
+}
+
+// isPlainCSS reports whether s can be found by css only, no js helper needed
+func (s Selector) isPlainCSS() bool {
+	return s.XPath == "" && s.Root == nil && !s.byText() && !s.pierceShadow()
+}
+
+// pierceShadow reports whether css descends into shadow roots
+func (s Selector) pierceShadow() bool {
+	return s.XPath == "" && strings.Contains(s.CSS, ShadowPierce)
+}
+
+func isXPath(s string) bool {
+	for _, prefix := range []string{xpathPrefix, "/", "./", "(", ".."} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// byText reports whether elements are filtered by text
+func (s Selector) byText() bool {
+	return s.Regex != "" || s.Text != ""
+}
+
+// textRegex returns the javascript regex source and flags to match elements' text
+func (s Selector) textRegex() (source, flags string) {
+	switch {
+	case s.Regex != "":
+		source = s.Regex
+	case s.Exact:
+		source = "^" + regexp.QuoteMeta(s.Text) + "$"
+	default:
+		source = regexp.QuoteMeta(s.Text)
+	}
+
+	if s.Regex != "" {
+		flags = s.regexFlags
+	}
+	if s.CaseInsensitive {
+		flags = "i" + flags
+	}
+	return
+}
+
+// index resolves Nth against n matched elements, python style index is supported
+func (s Selector) index(n int) (int, bool) {
+	i := 0
+	if s.Nth != nil {
+		i = *s.Nth
+	}
+	if i < 0 {
+		i += n
+	}
+	return i, i >= 0 && i < n
+}
+
+const queryNodesJS = `function(css, xpath) {
+	const root = this && this.querySelectorAll ? this : document
+	if (!xpath) {
+		const parts = css.split("` + ShadowPierce + `").map((s) => s.trim())
+		let roots = [root]
+		for (const part of parts.slice(0, -1)) {
+			roots = roots.flatMap((r) => Array.from(r.querySelectorAll(part))).map((h) => h.shadowRoot).filter(Boolean)
+		}
+		return roots.flatMap((r) => Array.from(r.querySelectorAll(parts[parts.length - 1])))
+	}
+	const res = document.evaluate(xpath, root, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null)
+	const nodes = []
+	for (let i = 0; i < res.snapshotLength; i++) nodes.push(res.snapshotItem(i))
+	return nodes
+}`
+
+// elemTextJS is the text of element matched by text, same as rod's ElementR:
+// value or placeholder of input and textarea, selected options of select, innerText of others
+const elemTextJS = `function(e) {
+	let text
+	switch (e.tagName) {
+	case "INPUT":
+	case "TEXTAREA":
+		text = e.value || e.placeholder
+		break
+	case "SELECT":
+		text = Array.from(e.selectedOptions).map((el) => el.innerText).join()
+		break
+	case undefined:
+		text = e.textContent
+		break
+	default:
+		text = e.innerText
+	}
+	return text === undefined || text === null ? "" : String(text)
+}`
+
+const selectElemsJS = `function(css, xpath, source, flags) {
+	const nodes = (` + queryNodesJS + `).call(this, css, xpath)
+	if (source === "") return nodes
+	const reg = new RegExp(source, flags)
+	return nodes.filter((e) => reg.test((` + elemTextJS + `)(e)))
+}`
+
+const selectElemJS = `function(css, xpath, source, flags) {
+	const nodes = (` + queryNodesJS + `).call(this, css, xpath)
+	const reg = new RegExp(source, flags)
+	return nodes.find((e) => reg.test((` + elemTextJS + `)(e))) || null
+}`
+
+// evalOptions returns the js to find elements of s
+func (s Selector) evalOptions(all bool) *rod.EvalOptions {
+	source, flags := s.textRegex()
+	if !s.byText() {
+		source = ""
+	}
+	if all {
+		return rod.Eval(selectElemsJS, s.CSS, s.XPath, source, flags)
+	}
+	return rod.Eval(selectElemJS, s.CSS, s.XPath, source, flags)
+}
+
+// toSelector converts string, Selector or *Selector to Selector
+func toSelector(v interface{}) (Selector, error) {
+	switch v := v.(type) {
+	case string:
+		return ParseSelector(v), nil
+	case Selector:
+		return v, nil
+	case *Selector:
+		if v != nil {
+			return *v, nil
+		}
+	}
+	return Selector{}, fmt.Errorf("%w: unsupported selector type %T", ErrorSelNotFound, v)
+}
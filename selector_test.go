@@ -0,0 +1,162 @@
+package xbot
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/coghost/xutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type SelectorSuite struct {
+	suite.Suite
+}
+
+func TestSelector(t *testing.T) {
+	suite.Run(t, new(SelectorSuite))
+}
+
+func (s *SelectorSuite) Test_01_Parse() {
+	tests := []struct {
+		raw    string
+		want   Selector
+		source string
+	}{
+		{raw: "div.abc", want: Selector{CSS: "div.abc"}, source: ""},
+		{raw: "div.abc@@@Data & IT", want: Selector{CSS: "div.abc", Regex: "Data & IT"}, source: "Data & IT"},
+		{raw: "div.abc@@@---@@@$9.99", want: Selector{CSS: "div.abc", Text: "$9.99", Exact: true}, source: `^\$9\.99$`},
+		{raw: "div.abc@@@a@@@b", want: Selector{CSS: "div.abc", Regex: "a@@@b"}, source: "a@@@b"},
+		{raw: "a@@@/next/i", want: Selector{CSS: "a", Regex: "next", CaseInsensitive: true}, source: "next"},
+		{raw: "a@@@/^next$/m", want: Selector{CSS: "a", Regex: "^next$", regexFlags: "m"}, source: "^next$"},
+		{raw: "a@@@/next/x", want: Selector{CSS: "a", Regex: "/next/x"}, source: "/next/x"},
+	}
+
+	for _, tt := range tests {
+		got := ParseSelector(tt.raw)
+		s.Equal(tt.want, got, tt.raw)
+		s.Equal(tt.raw, got.String(), tt.raw)
+		if got.byText() {
+			source, _ := got.textRegex()
+			s.Equal(tt.source, source, tt.raw)
+		}
+	}
+}
+
+func (s *SelectorSuite) Test_02_Builder() {
+	sel := Sel("li").HasText("a.b").IgnoreCase().At(-1)
+	source, flags := sel.textRegex()
+	s.Equal(`a\.b`, source)
+	s.Equal("i", flags)
+	s.Equal(`li@@@a\.b`, sel.String())
+
+	i, ok := sel.index(3)
+	s.True(ok)
+	s.Equal(2, i)
+
+	_, ok = sel.At(3).index(3)
+	s.False(ok)
+
+	i, ok = Sel("li").index(3)
+	s.True(ok)
+	s.Equal(0, i)
+}
+
+func (s *SelectorSuite) Test_03_ToSelector() {
+	for _, v := range []interface{}{"li@@@x", Sel("li").MatchRegex("x"), &Selector{CSS: "li", Regex: "x"}} {
+		got, err := toSelector(v)
+		s.Nil(err)
+		s.Equal("li@@@x", got.String())
+	}
+
+	_, err := toSelector(1)
+	s.True(errors.Is(err, ErrorSelNotFound))
+
+	opt := BotOpts{CaseInsensitive: true}
+	s.True(opt.bindSelector(Sel("li")).CaseInsensitive)
+}
+
+func (s *SelectorSuite) Test_04_XPath() {
+	tests := []struct {
+		raw  string
+		want Selector
+		str  string
+	}{
+		{raw: "//table//td[2]", want: Selector{XPath: "//table//td[2]"}},
+		{raw: "(//a)[1]", want: Selector{XPath: "(//a)[1]"}},
+		{raw: ".//a@@@Next", want: Selector{XPath: ".//a", Regex: "Next"}},
+		{raw: "xpath=//a", want: Selector{XPath: "//a"}, str: "//a"},
+		{raw: "xpath=a/b", want: Selector{XPath: "a/b"}},
+		{raw: "div > a", want: Selector{CSS: "div > a"}},
+	}
+
+	for _, tt := range tests {
+		got := ParseSelector(tt.raw)
+		s.Equal(tt.want, got, tt.raw)
+		s.Equal(xutil.AorB(tt.str, tt.raw), got.String(), tt.raw)
+	}
+
+	s.False(XPath("//a").isPlainCSS())
+	s.True(Sel("a").isPlainCSS())
+	s.True(XPath("").IsEmpty())
+}
+
+func (s *SelectorSuite) Test_05_ShadowPierce() {
+	sel := ParseSelector("my-form >>> my-input >>> input@@@---@@@Email")
+	s.Equal("my-form >>> my-input >>> input", sel.CSS)
+	s.True(sel.Exact)
+	s.True(sel.pierceShadow())
+	s.False(sel.isPlainCSS())
+	s.Equal("my-form >>> my-input >>> input@@@---@@@Email", sel.String())
+
+	s.False(Sel("div > input").pierceShadow())
+}
+
+func (s *SelectorSuite) Test_06_RegexFlags() {
+	for raw, want := range map[string][2]string{
+		"a@@@/next/i":  {"next", "i"},
+		"a@@@/next/gi": {"next", "i"},
+		"a@@@/next/mi": {"next", "im"},
+		"a@@@/next/ii": {"/next/ii", ""},
+		"a@@@/next/":   {"next", ""},
+	} {
+		source, flags := ParseSelector(raw).textRegex()
+		s.Equal(want, [2]string{source, flags}, raw)
+	}
+
+	s.Equal("a@@@/next/im", ParseSelector("a@@@/next/mi").String())
+	s.Equal("a@@@/next/i", Sel("a").MatchRegex("next").IgnoreCase().String())
+}
+
+// Test_07_ElemText runs the text filter of selectElemsJS on stub elements with node if available
+func (s *SelectorSuite) Test_07_ElemText() {
+	out := runNodeJS(s.T(), `
+const elems = [
+	{tagName: "A", innerText: "Next page"},
+	{tagName: "INPUT", value: "", placeholder: "next keyword"},
+	{tagName: "INPUT", value: "NEXT"},
+	{tagName: "TEXTAREA", value: "prev"},
+	{tagName: "SELECT", selectedOptions: [{innerText: "next 20"}]},
+	{tagName: undefined, textContent: "next node"},
+	{tagName: "SPAN", innerText: undefined},
+];
+const root = {querySelectorAll: () => elems};
+const found = (`+selectElemsJS+`).call(root, "*", "", "next", "i");
+console.log(JSON.stringify(found.map((e) => elems.indexOf(e))))`)
+
+	s.JSONEq(`[0, 1, 2, 4, 5]`, out)
+}
+
+// runNodeJS runs src with node and returns its output, it skips if node is not found
+func runNodeJS(t *testing.T, src string) string {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found")
+	}
+
+	out, err := exec.Command(node, "-e", src).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	return string(out)
+}
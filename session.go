@@ -0,0 +1,213 @@
+package xbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rs/zerolog/log"
+)
+
+// SessionVersion is the version of session file written by SaveSession
+const SessionVersion = 1
+
+var ErrorSessionVersion = errors.New("unsupported session version")
+
+// Session is the cookies and web storages of bot, saved by SaveSession
+type Session struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	URL     string    `json:"url"`
+
+	Cookies []*proto.NetworkCookie `json:"cookies"`
+	Origins []*OriginStorage       `json:"origins"`
+}
+
+// OriginStorage is the localStorage and sessionStorage of an origin
+type OriginStorage struct {
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"local_storage"`
+	SessionStorage map[string]string `json:"session_storage"`
+}
+
+const exportStorageJS = `() => {
+	const dump = (s) => Object.fromEntries(Object.keys(s).map((k) => [k, s.getItem(k)]))
+	return {origin: location.origin, local_storage: dump(localStorage), session_storage: dump(sessionStorage)}
+}`
+
+const importStorageJS = `(origin, local, session) => {
+	if (location.origin !== origin) return false
+	Object.entries(local || {}).forEach(([k, v]) => localStorage.setItem(k, v))
+	Object.entries(session || {}).forEach(([k, v]) => sessionStorage.setItem(k, v))
+	return true
+}`
+
+// sessionRestoredKey marks the storages of a tab are restored, so they are restored only once
+const sessionRestoredKey = "__xbot_session_restored__"
+
+// SaveSession saves cookies of bot's browser context,
+// and localStorage/sessionStorage of current origin (only) to file at path
+func (b *Bot) SaveSession(path string) error {
+	sess, err := b.ExportSession()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path = expandPath(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// LoadSession loads session saved by SaveSession into bot
+func (b *Bot) LoadSession(path string) error {
+	sess, err := ReadSessionFile(path)
+	if err != nil {
+		return err
+	}
+	return b.ImportSession(sess)
+}
+
+// ExportSession returns cookies of bot's browser context, and web storages of current origin.
+//
+// WARN: only the storages of current origin are exported, storages of other origins visited before are not,
+// export on each origin and merge Session.Origins if more are needed
+func (b *Bot) ExportSession() (*Session, error) {
+	pg := b.Pg.Timeout(b.shortToSec)
+
+	cookies, err := proto.NetworkGetAllCookies{}.Call(pg)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{
+		Version: SessionVersion,
+		SavedAt: time.Now(),
+		Cookies: cookies.Cookies,
+	}
+
+	info, err := pg.Info()
+	if err != nil {
+		return nil, err
+	}
+	sess.URL = info.URL
+
+	obj, err := pg.Eval(exportStorageJS)
+	if err != nil {
+		return nil, err
+	}
+
+	var storage OriginStorage
+	if err := obj.Value.Unmarshal(&storage); err != nil {
+		return nil, err
+	}
+	// about:blank and data urls have opaque origin "null"
+	if storage.Origin != "" && storage.Origin != "null" {
+		sess.Origins = append(sess.Origins, &storage)
+	}
+
+	return sess, nil
+}
+
+// ImportSession sets cookies of sess, and restores web storages:
+//   - storages of current origin are restored immediately
+//   - storages of other origins are restored once the origin is opened in this page
+func (b *Bot) ImportSession(sess *Session) error {
+	if err := checkSessionVersion(sess); err != nil {
+		return err
+	}
+
+	pg := b.Pg.Timeout(b.shortToSec)
+	if len(sess.Cookies) != 0 {
+		if err := pg.SetCookies(toCookieParams(sess.Cookies)); err != nil {
+			return err
+		}
+	}
+
+	for _, storage := range sess.Origins {
+		obj, err := pg.Eval(importStorageJS, storage.Origin, storage.LocalStorage, storage.SessionStorage)
+		if err != nil {
+			return err
+		}
+		if obj.Value.Bool() {
+			continue
+		}
+
+		js, err := restoreStorageOnNewDocument(storage)
+		if err != nil {
+			return err
+		}
+		if _, err := pg.EvalOnNewDocument(js); err != nil {
+			return err
+		}
+		log.Debug().Str("origin", storage.Origin).Msg("storages will be restored when origin is opened")
+	}
+
+	return nil
+}
+
+// ReadSessionFile reads session file and checks its version
+func ReadSessionFile(path string) (*Session, error) {
+	data, err := os.ReadFile(expandPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, err
+	}
+
+	return &sess, checkSessionVersion(&sess)
+}
+
+func checkSessionVersion(sess *Session) error {
+	if sess.Version <= 0 || sess.Version > SessionVersion {
+		return fmt.Errorf("%w: %d, supports up to %d", ErrorSessionVersion, sess.Version, SessionVersion)
+	}
+	return nil
+}
+
+func restoreStorageOnNewDocument(storage *OriginStorage) (string, error) {
+	args, err := json.Marshal([]interface{}{storage.Origin, storage.LocalStorage, storage.SessionStorage})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`(() => {
+	if (sessionStorage.getItem(%[1]q)) return
+	if ((%[2]s)(...%[3]s)) sessionStorage.setItem(%[1]q, "1")
+})()`, sessionRestoredKey, importStorageJS, args), nil
+}
+
+func toCookieParams(cookies []*proto.NetworkCookie) []*proto.NetworkCookieParam {
+	var nodes []*proto.NetworkCookieParam
+	for _, cookie := range cookies {
+		port := cookie.SourcePort
+		nodes = append(nodes, &proto.NetworkCookieParam{
+			Name:         cookie.Name,
+			Value:        cookie.Value,
+			Domain:       cookie.Domain,
+			Path:         cookie.Path,
+			Secure:       cookie.Secure,
+			HTTPOnly:     cookie.HTTPOnly,
+			SameSite:     cookie.SameSite,
+			Expires:      cookie.Expires,
+			Priority:     cookie.Priority,
+			SameParty:    cookie.SameParty,
+			SourceScheme: cookie.SourceScheme,
+			SourcePort:   &port,
+		})
+	}
+	return nodes
+}
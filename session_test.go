@@ -0,0 +1,141 @@
+package xbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/suite"
+)
+
+type SessionSuite struct {
+	suite.Suite
+}
+
+func TestSession(t *testing.T) {
+	suite.Run(t, new(SessionSuite))
+}
+
+func (s *SessionSuite) write(sess *Session) string {
+	path := filepath.Join(s.T().TempDir(), "session.json")
+	data, err := json.Marshal(sess)
+	s.Require().NoError(err)
+	s.Require().NoError(os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func (s *SessionSuite) Test_01_ReadSessionFile() {
+	want := &Session{
+		Version: SessionVersion,
+		URL:     "https://example.com/",
+		Cookies: []*proto.NetworkCookie{{Name: "sid", Value: "1", Domain: "example.com", Path: "/"}},
+		Origins: []*OriginStorage{{
+			Origin:         "https://example.com",
+			LocalStorage:   map[string]string{"token": "abc"},
+			SessionStorage: map[string]string{"tab": "2"},
+		}},
+	}
+
+	got, err := ReadSessionFile(s.write(want))
+	s.Require().NoError(err)
+	s.Equal(want.URL, got.URL)
+	s.Equal(want.Cookies, got.Cookies)
+	s.Equal(want.Origins, got.Origins)
+}
+
+func (s *SessionSuite) Test_02_Version() {
+	for _, v := range []int{0, SessionVersion + 1} {
+		_, err := ReadSessionFile(s.write(&Session{Version: v}))
+		s.True(errors.Is(err, ErrorSessionVersion), v)
+	}
+}
+
+func (s *SessionSuite) Test_03_CookieParams() {
+	params := toCookieParams([]*proto.NetworkCookie{
+		{Name: "a", SourcePort: 80},
+		{Name: "b", SourcePort: 443},
+	})
+	s.Len(params, 2)
+	s.Equal(80, *params[0].SourcePort)
+	s.Equal(443, *params[1].SourcePort)
+}
+
+// Test_04_RestoreScript runs the script restoring storages on new document with node if available
+func (s *SessionSuite) Test_04_RestoreScript() {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		s.T().Skip("node not found")
+	}
+
+	js, err := restoreStorageOnNewDocument(&OriginStorage{
+		Origin:         "https://example.com",
+		LocalStorage:   map[string]string{"token": "abc"},
+		SessionStorage: map[string]string{"tab": "2"},
+	})
+	s.Require().NoError(err)
+
+	// run the script twice on a document of origin, with localStorage changed by page in between
+	run := func(origin string) string {
+		src := fmt.Sprintf(`
+const store = () => { const m = {}; return {getItem: (k) => k in m ? m[k] : null, setItem: (k, v) => { m[k] = String(v) }, m} };
+globalThis.location = {origin: %q};
+globalThis.localStorage = store();
+globalThis.sessionStorage = store();
+%s;
+localStorage.setItem("token", "changed");
+%s;
+console.log(JSON.stringify([localStorage.m, sessionStorage.m]))`, origin, js, js)
+		out, err := exec.Command(node, "-e", src).CombinedOutput()
+		s.Require().NoError(err, string(out))
+		return string(out)
+	}
+
+	s.JSONEq(`[{"token": "changed"}, {"tab": "2", "`+sessionRestoredKey+`": "1"}]`, run("https://example.com"), "restored only once")
+	s.JSONEq(`[{"token": "changed"}, {}]`, run("https://other.example.com"), "other origins are untouched")
+}
+
+func (s *SessionSuite) Test_05_ExportImport() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>session</body></html>"))
+	}))
+	defer srv.Close()
+
+	src, err := NewBotE(BotUserAgent(UA), BotHeadless(true))
+	if err != nil {
+		s.T().Skipf("browser not available: %v", err)
+	}
+	defer src.Close()
+
+	s.Require().NoError(src.GetPageE(srv.URL))
+	_, err = src.Pg.Eval(`() => {
+		document.cookie = "sid=1; path=/"
+		localStorage.setItem("token", "abc")
+		sessionStorage.setItem("tab", "2")
+	}`)
+	s.Require().NoError(err)
+
+	sess, err := src.ExportSession()
+	s.Require().NoError(err)
+	s.Require().Len(sess.Origins, 1)
+	s.Equal(srv.URL, sess.Origins[0].Origin)
+	s.Equal(map[string]string{"token": "abc"}, sess.Origins[0].LocalStorage)
+
+	// an isolated bot imports on about:blank, storages are restored once the origin is opened
+	dst, err := NewIncognitoBot(src.Brw, "", BotUserAgent(UA))
+	s.Require().NoError(err)
+	defer func() { _ = dst.Brw.Close() }()
+
+	s.Require().NoError(dst.ImportSession(sess))
+	s.Require().NoError(dst.GetPageE(srv.URL))
+
+	obj, err := dst.Pg.Eval(`() => [document.cookie, localStorage.getItem("token"), sessionStorage.getItem("tab")]`)
+	s.Require().NoError(err)
+	s.Equal(`["sid=1","abc","2"]`, obj.Value.JSON("", ""))
+}
@@ -0,0 +1,308 @@
+package xbot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxTableSpan caps colspan/rowspan, so a malformed table won't blow up
+const maxTableSpan = 1000
+
+// TableHeaderSep joins the texts of multi-row headers, e.g. "Price / USD"
+var TableHeaderSep = " / "
+
+// TableCell is a cell of table, spanned cells are copied to every position they cover
+type TableCell struct {
+	Text string `json:"text"`
+	// Links are the absolute urls of <a href> in the cell
+	Links []string `json:"links,omitempty"`
+}
+
+// Table is extracted by ExtractTable
+type Table struct {
+	// Header is the column names, texts of multi-row headers are joined by TableHeaderSep
+	Header []string
+	Cells  [][]TableCell
+}
+
+// rawTableCell is returned by tableJS
+type rawTableCell struct {
+	TableCell
+	TH      bool `json:"th"`
+	ColSpan int  `json:"colspan"`
+	RowSpan int  `json:"rowspan"`
+}
+
+type rawTableRow struct {
+	Head  bool           `json:"head"`
+	Cells []rawTableCell `json:"cells"`
+}
+
+// tableJS reads rows of the table, which is the element itself or the first table within it
+const tableJS = `function() {
+	const table = this.tagName === "TABLE" ? this : this.querySelector("table")
+	if (!table) return null
+	return Array.from(table.rows).map((tr) => ({
+		head: !!tr.parentElement && tr.parentElement.tagName === "THEAD",
+		cells: Array.from(tr.cells).map((c) => ({
+			text: (c.innerText || c.textContent || "").trim(),
+			th: c.tagName === "TH",
+			colspan: c.colSpan || 1,
+			rowspan: c.rowSpan || 1,
+			links: Array.from(c.querySelectorAll("a[href]")).map((a) => a.href),
+		})),
+	}))
+}`
+
+// ExtractTable extracts the table of selector (or the first table within it) in one evaluation
+//
+//   - header rows are rows in <thead>, or the leading rows of all <th> if no <thead>
+//   - colspan/rowspan are expanded, so every row has the same number of cells
+func (b *Bot) ExtractTable(selector interface{}, opts ...BotOptFunc) (*Table, error) {
+	elem := b.RecalculateElem(selector, opts...)
+	if elem == nil {
+		if e := b.ctxErr(); e != nil {
+			return nil, e
+		}
+		return nil, ErrorSelNotFound
+	}
+
+	obj, err := elem.Timeout(b.mediumToSec).Eval(tableJS)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []rawTableRow
+	if err := obj.Value.Unmarshal(&rows); err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		return nil, fmt.Errorf("%w: no table in %v", ErrorSelNotFound, selector)
+	}
+
+	return newTable(rows), nil
+}
+
+func (b *Bot) MustExtractTable(selector interface{}, opts ...BotOptFunc) *Table {
+	t, err := b.ExtractTable(selector, opts...)
+	b.PanicIfErr(err)
+	return t
+}
+
+func newTable(rows []rawTableRow) *Table {
+	grid := expandSpans(rows)
+
+	heads := headerRows(rows)
+	t := &Table{Cells: grid[heads:]}
+	if heads != 0 {
+		t.Header = mergeHeader(grid[:heads])
+	}
+	return t
+}
+
+// headerRows returns the number of leading header rows
+func headerRows(rows []rawTableRow) (n int) {
+	hasHead := false
+	for _, row := range rows {
+		hasHead = hasHead || row.Head
+	}
+
+	for _, row := range rows {
+		if hasHead && !row.Head {
+			break
+		}
+		if !hasHead && !allTH(row) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+func allTH(row rawTableRow) bool {
+	if len(row.Cells) == 0 {
+		return false
+	}
+	for _, c := range row.Cells {
+		if !c.TH {
+			return false
+		}
+	}
+	return true
+}
+
+func clampSpan(n int) int {
+	if n < 1 {
+		return 1
+	}
+	if n > maxTableSpan {
+		return maxTableSpan
+	}
+	return n
+}
+
+// expandSpans places cells to a grid, a cell spanning multiple rows/cols is copied to each position
+func expandSpans(rows []rawTableRow) [][]TableCell {
+	type pos struct{ row, col int }
+	pending := map[pos]TableCell{}
+
+	width := 0
+	grid := make([][]TableCell, 0, len(rows))
+	for r, row := range rows {
+		var out []TableCell
+		col := 0
+		fillPending := func() {
+			for {
+				cell, ok := pending[pos{r, col}]
+				if !ok {
+					return
+				}
+				out = append(out, cell)
+				delete(pending, pos{r, col})
+				col++
+			}
+		}
+
+		for _, cell := range row.Cells {
+			fillPending()
+			colSpan, rowSpan := clampSpan(cell.ColSpan), clampSpan(cell.RowSpan)
+			for c := 0; c < colSpan; c++ {
+				out = append(out, cell.TableCell)
+				for rr := 1; rr < rowSpan && r+rr < len(rows); rr++ {
+					pending[pos{r + rr, col}] = cell.TableCell
+				}
+				col++
+			}
+		}
+
+		// cells spanned from above after the last cell of this row
+		last := -1
+		for p := range pending {
+			if p.row == r && p.col > last {
+				last = p.col
+			}
+		}
+		for ; col <= last; col++ {
+			cell, ok := pending[pos{r, col}]
+			if ok {
+				delete(pending, pos{r, col})
+			}
+			out = append(out, cell)
+		}
+
+		if len(out) > width {
+			width = len(out)
+		}
+		grid = append(grid, out)
+	}
+
+	for i, row := range grid {
+		for len(row) < width {
+			row = append(row, TableCell{})
+		}
+		grid[i] = row
+	}
+	return grid
+}
+
+// mergeHeader joins distinct texts of each column top-down
+func mergeHeader(rows [][]TableCell) []string {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	header := make([]string, len(rows[0]))
+	for col := range header {
+		var parts []string
+		for _, row := range rows {
+			txt := row[col].Text
+			if txt != "" && (len(parts) == 0 || parts[len(parts)-1] != txt) {
+				parts = append(parts, txt)
+			}
+		}
+		header[col] = strings.Join(parts, TableHeaderSep)
+	}
+	return header
+}
+
+// Rows returns texts of cells
+func (t *Table) Rows() [][]string {
+	rows := make([][]string, 0, len(t.Cells))
+	for _, cells := range t.Cells {
+		row := make([]string, 0, len(cells))
+		for _, c := range cells {
+			row = append(row, c.Text)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Keys returns the keys of Records: header names made unique by suffix "_2", "_3"...,
+// and "col_N" for columns without name
+func (t *Table) Keys() []string {
+	width := len(t.Header)
+	if len(t.Cells) != 0 && len(t.Cells[0]) > width {
+		width = len(t.Cells[0])
+	}
+
+	keys := make([]string, width)
+	seen := map[string]int{}
+	for i := range keys {
+		key := ""
+		if i < len(t.Header) {
+			key = t.Header[i]
+		}
+		if key == "" {
+			key = fmt.Sprintf("col_%d", i+1)
+		}
+
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s_%d", key, n)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// Records returns rows as maps keyed by Keys
+func (t *Table) Records() []map[string]string {
+	keys := t.Keys()
+	records := make([]map[string]string, 0, len(t.Cells))
+	for _, row := range t.Rows() {
+		rec := make(map[string]string, len(keys))
+		for i, v := range row {
+			rec[keys[i]] = v
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// WriteCSV writes Keys as the first line, then the rows
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Keys()); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows()); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteJSONL writes a json object of Records per line
+func (t *Table) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, rec := range t.Records() {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
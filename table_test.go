@@ -0,0 +1,88 @@
+package xbot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TableSuite struct {
+	suite.Suite
+}
+
+func TestTable(t *testing.T) {
+	suite.Run(t, new(TableSuite))
+}
+
+func th(text string, colspan, rowspan int) rawTableCell {
+	return rawTableCell{TableCell: TableCell{Text: text}, TH: true, ColSpan: colspan, RowSpan: rowspan}
+}
+
+func td(text string, colspan, rowspan int) rawTableCell {
+	return rawTableCell{TableCell: TableCell{Text: text}, ColSpan: colspan, RowSpan: rowspan}
+}
+
+func (s *TableSuite) Test_01_Spans() {
+	// | Name (rowspan 2) | Price (colspan 2) |
+	// |                  | USD  | EUR        |
+	// | a (rowspan 2)    | 1    | 2          |
+	// |                  | 3 (colspan 2)     |
+	rows := []rawTableRow{
+		{Cells: []rawTableCell{th("Name", 1, 2), th("Price", 2, 1)}},
+		{Cells: []rawTableCell{th("USD", 1, 1), th("EUR", 1, 1)}},
+		{Cells: []rawTableCell{td("a", 1, 2), td("1", 1, 1), td("2", 1, 1)}},
+		{Cells: []rawTableCell{td("3", 2, 1)}},
+	}
+
+	t := newTable(rows)
+	s.Equal([]string{"Name", "Price / USD", "Price / EUR"}, t.Header)
+	s.Equal([][]string{{"a", "1", "2"}, {"a", "3", "3"}}, t.Rows())
+}
+
+func (s *TableSuite) Test_02_TrailingRowSpan() {
+	rows := []rawTableRow{
+		{Cells: []rawTableCell{td("a", 1, 1), td("b", 1, 1), td("c", 1, 3)}},
+		{Cells: []rawTableCell{td("d", 1, 1)}},
+		{Cells: []rawTableCell{}},
+	}
+
+	t := newTable(rows)
+	s.Nil(t.Header)
+	s.Equal([][]string{{"a", "b", "c"}, {"d", "", "c"}, {"", "", "c"}}, t.Rows())
+}
+
+func (s *TableSuite) Test_03_HeaderRows() {
+	s.Equal(1, headerRows([]rawTableRow{
+		{Head: true, Cells: []rawTableCell{td("x", 1, 1)}},
+		{Cells: []rawTableCell{th("y", 1, 1)}},
+	}))
+	s.Equal(2, headerRows([]rawTableRow{
+		{Cells: []rawTableCell{th("x", 1, 1)}},
+		{Cells: []rawTableCell{th("y", 1, 1)}},
+		{Cells: []rawTableCell{th("z", 1, 1), td("1", 1, 1)}},
+	}))
+	s.Equal(0, headerRows([]rawTableRow{{Cells: []rawTableCell{td("1", 1, 1)}}}))
+}
+
+func (s *TableSuite) Test_04_Writers() {
+	t := &Table{
+		Header: []string{"name", "", "name"},
+		Cells:  [][]TableCell{{{Text: "a"}, {Text: "b,c"}, {Text: "<d>"}}},
+	}
+	s.Equal([]string{"name", "col_2", "name_2"}, t.Keys())
+	s.Equal([]map[string]string{{"name": "a", "col_2": "b,c", "name_2": "<d>"}}, t.Records())
+
+	var buf bytes.Buffer
+	s.Require().NoError(t.WriteCSV(&buf))
+	s.Equal("name,col_2,name_2\na,\"b,c\",<d>\n", buf.String())
+
+	buf.Reset()
+	s.Require().NoError(t.WriteJSONL(&buf))
+	s.Equal(`{"col_2":"b,c","name":"a","name_2":"<d>"}`+"\n", buf.String())
+}
+
+// Test_05_JSSyntax checks tableJS with node if available
+func (s *TableSuite) Test_05_JSSyntax() {
+	checkJSSyntax(s.T(), map[string]string{"table": tableJS})
+}
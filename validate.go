@@ -0,0 +1,94 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrorInvalidConfig = errors.New("invalid bot config")
+
+// ValidationError holds all problems found by BotConfig.Validate,
+// it can be checked by errors.Is(err, xbot.ErrorInvalidConfig)
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrorInvalidConfig, strings.Join(e.Problems, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrorInvalidConfig
+}
+
+// Validate checks BotConfig before launching browser,
+// and returns all problems at once as *ValidationError, or nil if nothing found
+func (c *BotConfig) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Width < 0 {
+		add("width should not be negative, got %d", c.Width)
+	}
+	if c.Height < 0 {
+		add("height should not be negative, got %d", c.Height)
+	}
+	if c.Steps <= 0 {
+		add("steps should be greater than 0, got %d", c.Steps)
+	}
+
+	// a slice keeps problems in a stable order
+	for _, t := range []struct {
+		name string
+		v    int
+	}{
+		{"page_timeout", c.PageTimeout},
+		{"long_timeout", c.LongTimeout},
+		{"medium_timeout", c.MediumTimeout},
+		{"short_timeout", c.ShortTimeout},
+		{"nap_timeout", c.NapTimeout},
+	} {
+		if t.v < 0 {
+			add("%s should not be negative, got %d", t.name, t.v)
+		}
+	}
+	if c.TimeoutScale < 0 {
+		add("timeout_scale should not be negative, got %v", c.TimeoutScale)
+	}
+
+	if c.ProxyLine != "" {
+		if _, err := parseProxyLine(c.ProxyLine); err != nil {
+			add("%s", err)
+		}
+	}
+	if c.ProxyServer != "" && c.ProxyLine != "" {
+		add("proxy_server and proxy_line should not be set at the same time")
+	}
+
+	if c.UserMode && c.Incognito {
+		add("user_mode cannot work with incognito")
+	}
+
+	if v := c.remoteServiceUrl; v != "" {
+		if u, err := url.Parse(v); err != nil || !isRemoteScheme(u.Scheme) || u.Host == "" {
+			add("remote_service_url %q should be with scheme ws://, wss://, http:// or https://", v)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+func isRemoteScheme(scheme string) bool {
+	switch scheme {
+	case "ws", "wss", "http", "https":
+		return true
+	}
+	return false
+}
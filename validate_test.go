@@ -0,0 +1,86 @@
+package xbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ValidateSuite struct {
+	suite.Suite
+}
+
+func TestValidate(t *testing.T) {
+	suite.Run(t, new(ValidateSuite))
+}
+
+func (s *ValidateSuite) Test_01_Default() {
+	s.Nil(NewDefaultBotCfg().Validate())
+}
+
+func (s *ValidateSuite) Test_02_Problems() {
+	tests := []struct {
+		name   string
+		modify func(c *BotConfig)
+		want   string
+	}{
+		{name: "negative width", modify: func(c *BotConfig) { c.Width = -1 }, want: "width"},
+		{name: "negative height", modify: func(c *BotConfig) { c.Height = -1 }, want: "height"},
+		{name: "zero steps", modify: func(c *BotConfig) { c.Steps = 0 }, want: "steps"},
+		{name: "negative timeout", modify: func(c *BotConfig) { c.MediumTimeout = -1 }, want: "medium_timeout"},
+		{name: "negative scale", modify: func(c *BotConfig) { c.TimeoutScale = -1 }, want: "timeout_scale"},
+		{name: "short proxy line", modify: func(c *BotConfig) { c.ProxyLine = "127.0.0.1:1080" }, want: "host:port:username:password"},
+		{name: "bad proxy port", modify: func(c *BotConfig) { c.ProxyLine = "127.0.0.1:port:u:p" }, want: "invalid port"},
+		{
+			name: "proxy server and line",
+			modify: func(c *BotConfig) {
+				c.ProxyServer = "127.0.0.1:1080"
+				c.ProxyLine = "127.0.0.1:1080:u:p"
+			},
+			want: "at the same time",
+		},
+		{
+			name: "user mode with incognito",
+			modify: func(c *BotConfig) {
+				c.UserMode = true
+				c.Incognito = true
+			},
+			want: "incognito",
+		},
+		{name: "remote without scheme", modify: func(c *BotConfig) { c.remoteServiceUrl = "127.0.0.1:7317" }, want: "remote_service_url"},
+	}
+
+	for _, tt := range tests {
+		cfg := NewDefaultBotCfg()
+		tt.modify(cfg)
+		err := cfg.Validate()
+		s.ErrorIs(err, ErrorInvalidConfig, tt.name)
+		s.ErrorContains(err, tt.want, tt.name)
+	}
+}
+
+func (s *ValidateSuite) Test_03_Aggregated() {
+	cfg := NewDefaultBotCfg()
+	cfg.Width, cfg.Height, cfg.Steps = -1, -1, 0
+	cfg.remoteServiceUrl = "ws://127.0.0.1:7317"
+
+	err := cfg.Validate()
+	var ve *ValidationError
+	s.ErrorAs(err, &ve)
+	s.Len(ve.Problems, 3)
+}
+
+func (s *ValidateSuite) Test_04_Ordered() {
+	cfg := NewDefaultBotCfg()
+	cfg.PageTimeout, cfg.ShortTimeout, cfg.NapTimeout = -1, -1, -1
+
+	for i := 0; i < 10; i++ {
+		var ve *ValidationError
+		s.ErrorAs(cfg.Validate(), &ve)
+		s.Equal([]string{
+			"page_timeout should not be negative, got -1",
+			"short_timeout should not be negative, got -1",
+			"nap_timeout should not be negative, got -1",
+		}, ve.Problems)
+	}
+}